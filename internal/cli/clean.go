@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sh0o0/gw/internal/fsutil"
+	"github.com/sh0o0/gw/internal/gitx"
+	"github.com/sh0o0/gw/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+func newCleanCmd() *cobra.Command {
+	var orphans, yes bool
+	cmd := &cobra.Command{
+		Use:   "clean [--orphans [--yes]]",
+		Short: "Clean up stale worktree references",
+		Long: `Run git worktree prune to drop references to worktrees that no longer exist.
+
+With --orphans, also list the directories in the worktree base path that git no
+longer knows, e.g. left behind by a crashed gw rm --bg or a manual git worktree
+remove, with their sizes, and delete them after confirmation. In the flat layout
+(gw.worktree.flat) only directories that point back to this repository are listed.
+Nothing inside a worktree is ever deleted, and a base that lies inside a worktree
+is refused.
+
+Examples:
+  gw clean --orphans
+  gw clean --orphans --yes`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if yes && !orphans {
+				return errors.New("--yes requires --orphans")
+			}
+			out, err := gitx.Cmd("", "worktree", "prune")
+			if err != nil {
+				return err
+			}
+			fmt.Print(out)
+			if !orphans {
+				return nil
+			}
+			return cleanOrphans(cmd.OutOrStdout(), yes)
+		},
+	}
+	cmd.Flags().BoolVar(&orphans, "orphans", false, "Also delete unregistered directories in the worktree base path")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Delete orphaned directories without asking")
+	return cmd
+}
+
+// orphanDir is an unregistered directory in the worktree base path.
+type orphanDir struct {
+	path string
+	size int64
+}
+
+func cleanOrphans(w io.Writer, yes bool) error {
+	paths, err := worktree.FindOrphans("")
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		out.Info("No orphaned worktree directories")
+		return nil
+	}
+	dirs := make([]orphanDir, len(paths))
+	runConcurrently(len(paths), func(i int) {
+		size, _ := fsutil.DirSize(paths[i])
+		dirs[i] = orphanDir{path: paths[i], size: size}
+	})
+	total, err := writeOrphanTable(w, dirs)
+	if err != nil {
+		return err
+	}
+	if !yes && !confirm(fmt.Sprintf("Delete %d orphaned directories (%s)?", len(dirs), formatSize(total))) {
+		return errors.New("clean cancelled; pass --yes to delete without asking")
+	}
+
+	success := 0
+	var failed []string
+	for _, d := range dirs {
+		out.Trash("Deleting orphaned directory: %s", out.Highlight(d.path))
+		if err := removeOrphan(d.path); err != nil {
+			failed = append(failed, d.path)
+			out.Error("Failed to delete %s\n  %v", d.path, err)
+			continue
+		}
+		success++
+	}
+	out.Summary(success, len(failed), "orphan")
+	if len(failed) > 0 {
+		return fmt.Errorf("failed directories: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// writeOrphanTable prints dirs with their sizes and returns the total size.
+func writeOrphanTable(w io.Writer, dirs []orphanDir) (int64, error) {
+	var total int64
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SIZE\tPATH")
+	for _, d := range dirs {
+		total += d.size
+		fmt.Fprintf(tw, "%s\t%s\n", formatSize(d.size), d.path)
+	}
+	fmt.Fprintf(tw, "%s\t(total)\n", formatSize(total))
+	return total, tw.Flush()
+}
+
+// formatSize renders n bytes with a binary unit, e.g. 1.5 MiB.
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sh0o0/gw/internal/worktree"
+)
+
+func TestCleanOrphans_shouldListSizesAndDelete_whenYes(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	repo := filepath.Join(home, "repo")
+	initTestRepo(t, repo)
+	t.Setenv("GW_CALLER_CWD", repo)
+
+	live, err := worktree.ComputeWorktreePath(repo, "feature/live")
+	if err != nil {
+		t.Fatalf("compute worktree path: %v", err)
+	}
+	runGit(t, repo, "worktree", "add", live, "-b", "feature/live")
+	orphan := filepath.Join(filepath.Dir(live), "leftover")
+	if err := os.MkdirAll(filepath.Join(orphan, "node_modules"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(orphan, "node_modules", "blob"), make([]byte, 2048), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := cleanOrphans(&buf, true); err != nil {
+		t.Fatalf("cleanOrphans: %v", err)
+	}
+	if !strings.Contains(buf.String(), "2.0 KiB  "+orphan) {
+		t.Fatalf("expected orphan with its size, got %q", buf.String())
+	}
+	if strings.Contains(buf.String(), live) {
+		t.Fatalf("expected registered worktree not listed, got %q", buf.String())
+	}
+	if _, err := os.Stat(orphan); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected orphan deleted, err=%v", err)
+	}
+	if _, err := os.Stat(live); err != nil {
+		t.Fatalf("expected registered worktree kept: %v", err)
+	}
+}
+
+func TestCleanOrphans_shouldKeepDirectories_whenNotConfirmed(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	repo := filepath.Join(home, "repo")
+	initTestRepo(t, repo)
+	t.Setenv("GW_CALLER_CWD", repo)
+
+	base, err := worktree.WorktreeBasePath(repo)
+	if err != nil {
+		t.Fatalf("worktree base path: %v", err)
+	}
+	orphan := filepath.Join(base, "leftover")
+	if err := os.MkdirAll(orphan, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	// Tests have no terminal, so the confirmation is refused.
+	if err := cleanOrphans(&bytes.Buffer{}, false); err == nil {
+		t.Fatal("expected cleanOrphans to be cancelled")
+	}
+	if _, err := os.Stat(orphan); err != nil {
+		t.Fatalf("expected orphan kept: %v", err)
+	}
+}
+
+func TestFormatSize_shouldUseBinaryUnits(t *testing.T) {
+	cases := map[int64]string{
+		0:       "0 B",
+		1023:    "1023 B",
+		1024:    "1.0 KiB",
+		1536:    "1.5 KiB",
+		5 << 20: "5.0 MiB",
+		3 << 30: "3.0 GiB",
+	}
+	for n, want := range cases {
+		if got := formatSize(n); got != want {
+			t.Errorf("formatSize(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestCleanOrphans_shouldRefuse_whenBaseIsThePrimary(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	repo := filepath.Join(home, "src", "github.com", "example", "repo")
+	initTestRepo(t, repo)
+	docs := filepath.Join(repo, "docs")
+	if err := os.MkdirAll(docs, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, repo, "config", "gw.worktree.base", "~/src")
+	t.Setenv("GW_CALLER_CWD", repo)
+
+	var buf bytes.Buffer
+	if err := cleanOrphans(&buf, true); !errors.Is(err, worktree.ErrBaseInWorktree) {
+		t.Fatalf("expected ErrBaseInWorktree, got %v", err)
+	}
+	if _, err := os.Stat(docs); err != nil {
+		t.Fatalf("expected docs kept: %v", err)
+	}
+}
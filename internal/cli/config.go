@@ -16,6 +16,7 @@ const (
 	configKeyAI              = "gw.ai"
 	configKeyWorktreeBase    = "gw.worktree.base"
 	configKeyWorktreeFlat    = "gw.worktree.flat"
+	configKeyRmTrash         = "gw.rm.trash"
 )
 
 type gwConfig struct {
@@ -24,6 +25,7 @@ type gwConfig struct {
 	HooksBackground bool
 	Editor          string
 	AI              string
+	RmTrash         bool
 }
 
 func loadConfig() gwConfig {
@@ -44,6 +46,9 @@ func loadConfig() gwConfig {
 	if v, err := gitx.ConfigGet("", configKeyAI); err == nil {
 		cfg.AI = v
 	}
+	if v, err := gitx.ConfigGet("", configKeyRmTrash); err == nil {
+		cfg.RmTrash = strings.EqualFold(v, "true")
+	}
 	return cfg
 }
 
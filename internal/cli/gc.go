@@ -0,0 +1,338 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/sh0o0/gw/internal/fsutil"
+	"github.com/sh0o0/gw/internal/gitx"
+	"github.com/sh0o0/gw/internal/state"
+	"github.com/sh0o0/gw/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+const (
+	configKeyGCMerged       = "gw.gc.merged"
+	configKeyGCMaxAge       = "gw.gc.max-age"
+	configKeyGCKeep         = "gw.gc.keep"
+	configKeyGCMaxWorktrees = "gw.gc.max-worktrees"
+)
+
+// gcPolicy is what gw gc removes, read from gw.gc.* config.
+type gcPolicy struct {
+	// Merged removes worktrees whose branch is merged.
+	Merged bool
+	// MaxAge removes worktrees idle for longer; zero disables it.
+	MaxAge time.Duration
+	// Keep lists branch globs that are never removed.
+	Keep []string
+	// MaxWorktrees removes the least recently active worktrees beyond this many
+	// (the primary is not counted); zero disables it.
+	MaxWorktrees int
+}
+
+func loadGCPolicy() (gcPolicy, error) {
+	var p gcPolicy
+	if v, err := gitx.ConfigGet("", configKeyGCMerged); err == nil {
+		p.Merged = strings.EqualFold(v, "true")
+	}
+	if v, err := gitx.ConfigGet("", configKeyGCMaxAge); err == nil && v != "" {
+		age, err := parseAge(v)
+		if err != nil {
+			return p, fmt.Errorf("%s: %w", configKeyGCMaxAge, err)
+		}
+		p.MaxAge = age
+	}
+	p.Keep, _ = gitx.ConfigGetAll("", configKeyGCKeep)
+	if v, err := gitx.ConfigGet("", configKeyGCMaxWorktrees); err == nil && v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return p, fmt.Errorf("%s: invalid number %q", configKeyGCMaxWorktrees, v)
+		}
+		p.MaxWorktrees = n
+	}
+	return p, nil
+}
+
+func (p gcPolicy) keeps(branch string) bool {
+	for _, g := range p.Keep {
+		if ok, _ := doublestar.Match(g, branch); ok {
+			return true
+		}
+	}
+	return false
+}
+
+type gcAction string
+
+const (
+	gcRemove       gcAction = "remove"
+	gcDeleteOrphan gcAction = "delete-orphan"
+	gcPrune        gcAction = "prune"
+)
+
+// gcItem is one step of a gc plan.
+type gcItem struct {
+	Action gcAction `json:"action"`
+	Branch string   `json:"branch,omitempty"`
+	Path   string   `json:"path"`
+	Reason string   `json:"reason"`
+}
+
+func newGCCmd() *cobra.Command {
+	var dryRun, asJSON, yes bool
+	cmd := &cobra.Command{
+		Use:   "gc [--dry-run] [--json] [--yes]",
+		Short: "Remove merged, idle and orphaned worktrees according to gw.gc.* config",
+		Long: `Build a clean-up plan from the gw.gc.* policy and run it.
+
+The plan combines:
+  - worktrees whose branch is merged (gw.gc.merged=true)
+  - worktrees idle longer than gw.gc.max-age (last commit, newest file or last
+    gw go)
+  - the least recently active worktrees beyond gw.gc.max-worktrees
+  - orphaned directories in the worktree base that git no longer knows
+  - stale administrative entries removed by git worktree prune
+
+The primary and current worktrees, dirty worktrees, locked worktrees, protected
+worktrees (gw pin, gw.protect) and branches matching a gw.gc.keep glob are never
+removed. Branches with unpushed, unmerged commits are kept as with gw rm.
+
+Examples:
+  gw gc --dry-run
+  gw gc --dry-run --json
+  gw gc --yes          # e.g. from cron`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			policy, err := loadGCPolicy()
+			if err != nil {
+				return err
+			}
+			root, _ := gitx.Root("")
+			resolver := gitx.NewBranchStatusResolver(root)
+			plan, skipped, err := buildGCPlan(policy, time.Now(), func(path, branch string) bool {
+				st, err := resolver.Status(path, branch)
+				return err == nil && st == gitx.BranchStatusMerged
+			})
+			if err != nil {
+				return err
+			}
+			for _, s := range skipped {
+				out.Info("Skipping %s", s)
+			}
+			if asJSON {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(plan); err != nil {
+					return err
+				}
+			} else if len(plan) > 0 {
+				if err := writeGCTable(cmd.OutOrStdout(), plan); err != nil {
+					return err
+				}
+			}
+			if len(plan) == 0 {
+				out.Info("Nothing to clean up")
+				return nil
+			}
+			if dryRun {
+				return nil
+			}
+			if !yes && !confirm(fmt.Sprintf("Run %d step(s)?", len(plan))) {
+				return errors.New("gc cancelled; pass --yes to run without asking")
+			}
+			return runGCPlan(plan)
+		},
+	}
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Only print the plan")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print the plan as JSON")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Run the plan without asking")
+	cmd.MarkFlagsMutuallyExclusive("dry-run", "yes")
+	return cmd
+}
+
+// buildGCPlan decides what gw gc removes. merged reports whether a worktree's branch is
+// merged. It also returns a note for every worktree the policy matched but that is
+// spared because it is dirty.
+func buildGCPlan(p gcPolicy, now time.Time, merged func(path, branch string) bool) ([]gcItem, []string, error) {
+	wts, err := gitx.ListWorktrees("")
+	if err != nil {
+		return nil, nil, err
+	}
+	current, _ := gitx.CurrentWorktreePath("")
+	primaryPath, _ := primaryWorktreePath()
+	visits, err := state.Visits("")
+	if err != nil {
+		visits = map[string]time.Time{}
+	}
+	prot := worktree.LoadProtection("")
+
+	type candidate struct {
+		wt         gitx.Worktree
+		lastActive time.Time
+		removable  bool
+	}
+	plan := []gcItem{}
+	var skipped []string
+	var remaining []candidate
+	for _, wt := range wts {
+		if fsutil.SamePath(wt.Path, primaryPath) {
+			continue
+		}
+		dirty, err := gitx.DirtyCount(wt.Path)
+		if err != nil {
+			// Missing worktrees are handled by prune below.
+			continue
+		}
+		c := candidate{wt: wt, lastActive: worktreeLastActive(wt.Path, visits)}
+		branch := wt.Branch
+		if branch == "HEAD" {
+			branch = ""
+		}
+		c.removable = !fsutil.SamePath(wt.Path, current) && dirty == 0 && !(branch != "" && p.keeps(branch)) &&
+			prot.Reason(branch) == "" && !wt.Locked
+
+		reason := ""
+		switch {
+		case p.Merged && branch != "" && merged(wt.Path, branch):
+			reason = "merged"
+		case p.MaxAge > 0 && now.Sub(c.lastActive) >= p.MaxAge:
+			reason = "idle " + formatAge(now.Sub(c.lastActive))
+		}
+		if reason != "" && c.removable {
+			plan = append(plan, gcItem{Action: gcRemove, Branch: branch, Path: wt.Path, Reason: reason})
+			continue
+		}
+		if reason != "" && dirty > 0 && !fsutil.SamePath(wt.Path, current) {
+			skipped = append(skipped, fmt.Sprintf("%s (%s, but has uncommitted changes)", wt.Path, reason))
+		}
+		remaining = append(remaining, c)
+	}
+
+	if p.MaxWorktrees > 0 && len(remaining) > p.MaxWorktrees {
+		sort.SliceStable(remaining, func(i, k int) bool {
+			return remaining[i].lastActive.Before(remaining[k].lastActive)
+		})
+		excess := len(remaining) - p.MaxWorktrees
+		for _, c := range remaining {
+			if excess == 0 {
+				break
+			}
+			if !c.removable {
+				continue
+			}
+			branch := c.wt.Branch
+			if branch == "HEAD" {
+				branch = ""
+			}
+			plan = append(plan, gcItem{Action: gcRemove, Branch: branch, Path: c.wt.Path,
+				Reason: fmt.Sprintf("over max-worktrees (%d)", p.MaxWorktrees)})
+			excess--
+		}
+	}
+
+	orphans, err := worktree.FindOrphans("")
+	if errors.Is(err, worktree.ErrBaseInWorktree) {
+		out.Warn("Not looking for orphaned directories: %v", err)
+	} else if err != nil {
+		return nil, nil, err
+	}
+	for _, o := range orphans {
+		plan = append(plan, gcItem{Action: gcDeleteOrphan, Path: o, Reason: "not a registered worktree"})
+	}
+	prunable, err := gitx.PrunableWorktrees("")
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, pr := range prunable {
+		path := pr.Path
+		if path == "" {
+			path = pr.Admin
+		}
+		plan = append(plan, gcItem{Action: gcPrune, Path: path, Reason: pr.Reason})
+	}
+	return plan, skipped, nil
+}
+
+// removeOrphan deletes an orphaned directory after checking again that it neither
+// lies inside nor contains a registered worktree, which may have appeared since the
+// directory was listed.
+func removeOrphan(path string) error {
+	wts, err := gitx.ListWorktrees("")
+	if err != nil {
+		return err
+	}
+	p := fsutil.RealPath(path)
+	for _, wt := range wts {
+		w := fsutil.RealPath(wt.Path)
+		if fsutil.IsWithin(p, w) || fsutil.IsWithin(w, p) {
+			return fmt.Errorf("%s overlaps worktree %s; not deleting it", path, wt.Path)
+		}
+	}
+	return os.RemoveAll(path)
+}
+
+func writeGCTable(w io.Writer, plan []gcItem) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ACTION\tREASON\tBRANCH\tPATH")
+	for _, it := range plan {
+		branch := it.Branch
+		if branch == "" {
+			branch = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", it.Action, it.Reason, branch, it.Path)
+	}
+	return tw.Flush()
+}
+
+func runGCPlan(plan []gcItem) error {
+	rmOpts := removeOptions{trash: loadConfig().RmTrash}
+	success := 0
+	var failed []string
+	prune := false
+	for _, it := range plan {
+		var err error
+		switch it.Action {
+		case gcRemove:
+			err = removeWorktreeForeground(it.Path, rmOpts)
+		case gcDeleteOrphan:
+			out.Trash("Deleting orphaned directory: %s", out.Highlight(it.Path))
+			err = removeOrphan(it.Path)
+		case gcPrune:
+			prune = true
+			continue
+		}
+		if err != nil {
+			failed = append(failed, it.Path)
+			out.Error("Failed to %s %s\n  %v", it.Action, it.Path, err)
+			continue
+		}
+		success++
+	}
+	if prune {
+		if _, err := gitx.Cmd("", "worktree", "prune"); err != nil {
+			failed = append(failed, "worktree prune")
+			out.Error("Failed to prune worktrees\n  %v", err)
+		} else {
+			for _, it := range plan {
+				if it.Action == gcPrune {
+					success++
+				}
+			}
+		}
+	}
+	out.Summary(success, len(failed), "step")
+	if len(failed) > 0 {
+		return fmt.Errorf("failed: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
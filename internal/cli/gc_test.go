@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sh0o0/gw/internal/worktree"
+)
+
+func TestBuildGCPlan_shouldCombinePolicyOrphansAndPrune(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_STATE_HOME", "")
+
+	repo := filepath.Join(home, "repo")
+	initTestRepo(t, repo)
+	t.Setenv("GW_CALLER_CWD", repo)
+
+	add := func(branch string) string {
+		t.Helper()
+		p, err := worktree.ComputeWorktreePath(repo, branch)
+		if err != nil {
+			t.Fatalf("compute worktree path: %v", err)
+		}
+		runGit(t, repo, "worktree", "add", p, "-b", branch)
+		return p
+	}
+	merged := add("feature/merged")
+	kept := add("release/merged")
+	pinned := add("feature/pinned")
+	runGit(t, repo, "config", "--add", "gw.pin", "feature/pinned")
+	dirty := add("feature/dirty")
+	older := add("feature/older")
+	newer := add("feature/newer")
+	gone := add("feature/gone")
+	if err := os.WriteFile(filepath.Join(dirty, "draft.txt"), []byte("draft"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-48 * time.Hour)
+	ageTree(t, older, old)
+	if err := os.RemoveAll(gone); err != nil {
+		t.Fatal(err)
+	}
+	orphan := filepath.Join(filepath.Dir(merged), "leftover")
+	if err := os.MkdirAll(filepath.Join(orphan, "node_modules"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	policy := gcPolicy{Merged: true, Keep: []string{"release/**"}, MaxWorktrees: 4}
+	isMerged := func(path, branch string) bool {
+		return branch == "feature/merged" || branch == "release/merged" || branch == "feature/dirty" ||
+			branch == "feature/pinned"
+	}
+	plan, skipped, err := buildGCPlan(policy, time.Now(), isMerged)
+	if err != nil {
+		t.Fatalf("buildGCPlan: %v", err)
+	}
+
+	got := map[string]gcItem{}
+	for _, it := range plan {
+		got[it.Path] = it
+	}
+	if it := got[merged]; it.Action != gcRemove || it.Reason != "merged" {
+		t.Fatalf("expected merged worktree removed, got %+v", it)
+	}
+	if it := got[older]; it.Action != gcRemove || it.Reason != "over max-worktrees (4)" {
+		t.Fatalf("expected least recently active worktree removed, got %+v", it)
+	}
+	if it := got[orphan]; it.Action != gcDeleteOrphan {
+		t.Fatalf("expected orphan deleted, got %+v", it)
+	}
+	if it := got[gone]; it.Action != gcPrune {
+		t.Fatalf("expected missing worktree pruned, got %+v", it)
+	}
+	for _, p := range []string{kept, pinned, dirty, newer, repo} {
+		if it, ok := got[p]; ok {
+			t.Fatalf("expected %s kept, got %+v", p, it)
+		}
+	}
+	if len(plan) != 4 {
+		t.Fatalf("unexpected plan: %+v", plan)
+	}
+	if len(skipped) != 1 {
+		t.Fatalf("expected dirty merged worktree reported, got %v", skipped)
+	}
+
+	if err := runGCPlan(plan); err != nil {
+		t.Fatalf("runGCPlan: %v", err)
+	}
+	for _, p := range []string{merged, older, orphan} {
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Fatalf("expected %s deleted, err=%v", p, err)
+		}
+	}
+	if wts := runGitOutput(t, repo, "worktree", "list"); strings.Contains(wts, gone) {
+		t.Fatalf("expected %s pruned:\n%s", gone, wts)
+	}
+}
+
+func TestBuildGCPlan_shouldNotDeleteTrackedDirectories_whenBaseIsThePrimary(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_STATE_HOME", "")
+
+	// With origin github.com:example/repo the base ~/src/github.com/example/repo is
+	// the primary checkout itself.
+	repo := filepath.Join(home, "src", "github.com", "example", "repo")
+	initTestRepo(t, repo)
+	docs := filepath.Join(repo, "docs")
+	if err := os.MkdirAll(docs, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(docs, "guide.md"), []byte("guide"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, repo, "add", ".")
+	runGit(t, repo, "commit", "-m", "docs")
+	runGit(t, repo, "config", "gw.worktree.base", "~/src")
+	t.Setenv("GW_CALLER_CWD", repo)
+
+	plan, _, err := buildGCPlan(gcPolicy{Merged: true}, time.Now(), func(string, string) bool { return false })
+	if err != nil {
+		t.Fatalf("buildGCPlan: %v", err)
+	}
+	if len(plan) != 0 {
+		t.Fatalf("expected nothing planned, got %+v", plan)
+	}
+	if err := removeOrphan(docs); err == nil {
+		t.Fatal("expected removeOrphan to refuse a directory inside a worktree")
+	}
+	if _, err := os.Stat(filepath.Join(docs, "guide.md")); err != nil {
+		t.Fatalf("expected tracked docs kept: %v", err)
+	}
+}
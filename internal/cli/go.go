@@ -10,7 +10,11 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/sh0o0/gw/internal/fsutil"
 	"github.com/sh0o0/gw/internal/gitx"
+	"github.com/sh0o0/gw/internal/hooks"
+	"github.com/sh0o0/gw/internal/state"
+	"github.com/sh0o0/gw/internal/worktree"
 	"github.com/spf13/cobra"
 
 	fuzzyfinder "github.com/ktr0731/go-fuzzyfinder"
@@ -41,7 +45,7 @@ func switchInteractive(excludeCurrent bool, opts fuzzyDisplayOptions) error {
 	current, _ := gitx.CurrentWorktreePath("")
 	primaryPath, _ := primaryWorktreePath()
 	entries := buildWorktreeEntries(wts, func(wt gitx.Worktree) bool {
-		return excludeCurrent && wt.Path == current
+		return excludeCurrent && fsutil.SamePath(wt.Path, current)
 	}, primaryPath)
 	if len(entries) == 0 {
 		return errors.New("no worktrees available for selection")
@@ -88,12 +92,14 @@ func switchToPath(p string) error {
 	if err := navigateToRelativePath(p, rel); err != nil {
 		return err
 	}
+	// Visits feed gw rm --stale; losing one is harmless.
+	_ = state.RecordVisit("", p, time.Now())
 	if curBranch != "" && tgtBranch != "" {
 		fmt.Fprintf(os.Stderr, "Switched from [%s] to [%s]\n", curBranch, tgtBranch)
 	} else {
 		fmt.Fprintf(os.Stderr, "Switched to worktree: %s\n", p)
 	}
-	return nil
+	return runHook(hooks.PostSwitch, hookTarget{Branch: tgtBranch, Path: p}, loadConfig().HooksBackground)
 }
 
 const (
@@ -106,8 +112,13 @@ type worktreeEntry struct {
 	rawBranch string
 	path      string
 	isPrimary bool
-	status    atomic.Value
-	assignees atomic.Value
+	// protected is set for branches pinned with gw pin or matching gw.protect.
+	protected bool
+	// locked is set for worktrees locked with gw lock; lockReason may be empty.
+	locked     bool
+	lockReason string
+	status     atomic.Value
+	assignees  atomic.Value
 }
 
 type worktreeEntryBuilder struct {
@@ -119,6 +130,7 @@ func buildWorktreeEntries(wts []gitx.Worktree, skip func(gitx.Worktree) bool, pr
 	builder := &worktreeEntryBuilder{
 		entries: make([]*worktreeEntry, 0, len(wts)),
 	}
+	prot := worktree.LoadProtection("")
 	for _, wt := range wts {
 		if skip != nil && skip(wt) {
 			continue
@@ -131,15 +143,18 @@ func buildWorktreeEntries(wts []gitx.Worktree, skip func(gitx.Worktree) bool, pr
 		if wt.Branch != "" && wt.Branch != "HEAD" {
 			initial = loadingStatusDisplay
 		}
-		isPrimary := samePath(wt.Path, primaryPath)
+		isPrimary := fsutil.SamePath(wt.Path, primaryPath)
 		if isPrimary {
 			initial = ""
 		}
 		entry := &worktreeEntry{
-			branch:    branchLabel,
-			rawBranch: wt.Branch,
-			path:      wt.Path,
-			isPrimary: isPrimary,
+			branch:     branchLabel,
+			rawBranch:  wt.Branch,
+			path:       wt.Path,
+			isPrimary:  isPrimary,
+			protected:  prot.Reason(wt.Branch) != "",
+			locked:     wt.Locked,
+			lockReason: wt.LockReason,
 		}
 		entry.status.Store(initial)
 		builder.entries = append(builder.entries, entry)
@@ -177,6 +192,8 @@ func (e *worktreeEntry) display(opts fuzzyDisplayOptions, maxBranchLen int) stri
 	var b strings.Builder
 	if e.isPrimary {
 		b.WriteString("★ ")
+	} else if e.protected {
+		b.WriteString("⚑ ")
 	} else {
 		b.WriteString("  ")
 	}
@@ -211,6 +228,15 @@ func (e *worktreeEntry) display(opts fuzzyDisplayOptions, maxBranchLen int) stri
 		b.WriteString(e.path)
 	}
 
+	if e.locked {
+		b.WriteString("  (locked")
+		if e.lockReason != "" {
+			b.WriteString(": ")
+			b.WriteString(e.lockReason)
+		}
+		b.WriteString(")")
+	}
+
 	return b.String()
 }
 
@@ -286,53 +312,62 @@ func startWorktreeStatusLoader(collection *worktreeCollection, resolver *gitx.Br
 		return
 	}
 	go func() {
-		limit := runtime.NumCPU()
-		if limit < 1 {
-			limit = 1
-		}
-		sem := make(chan struct{}, limit)
-		var wg sync.WaitGroup
-		for _, entry := range collection.base {
+		runConcurrently(len(collection.base), func(i int) {
+			entry := collection.base[i]
 			if entry.isPrimary {
-				continue
+				return
 			}
 			if entry.rawBranch == "" || entry.rawBranch == "HEAD" {
-				continue
+				return
 			}
-			entry := entry
-			wg.Add(1)
-			go func() {
-				sem <- struct{}{}
-				defer func() {
-					<-sem
-					wg.Done()
-				}()
-				info := resolver.StatusInfo(entry.path, entry.rawBranch)
-				newStatus := info.Status.Display()
-				current := ""
-				if v := entry.status.Load(); v != nil {
-					current, _ = v.(string)
-				}
-				updated := false
-				if current != newStatus {
-					entry.status.Store(newStatus)
-					updated = true
-				}
-				newAssignees := strings.Join(info.Assignees, ",")
-				currentAssignees := ""
-				if v := entry.assignees.Load(); v != nil {
-					currentAssignees, _ = v.(string)
-				}
-				if currentAssignees != newAssignees {
-					entry.assignees.Store(newAssignees)
-					updated = true
-				}
-				if updated {
-					collection.triggerReload()
-				}
-			}()
-		}
-		wg.Wait()
+			info := resolver.StatusInfo(entry.path, entry.rawBranch)
+			newStatus := info.Status.Display()
+			current := ""
+			if v := entry.status.Load(); v != nil {
+				current, _ = v.(string)
+			}
+			updated := false
+			if current != newStatus {
+				entry.status.Store(newStatus)
+				updated = true
+			}
+			newAssignees := strings.Join(info.Assignees, ",")
+			currentAssignees := ""
+			if v := entry.assignees.Load(); v != nil {
+				currentAssignees, _ = v.(string)
+			}
+			if currentAssignees != newAssignees {
+				entry.assignees.Store(newAssignees)
+				updated = true
+			}
+			if updated {
+				collection.triggerReload()
+			}
+		})
 		collection.finalize()
 	}()
 }
+
+// runConcurrently calls fn for every index in [0, n) with at most NumCPU calls in flight
+// and returns once all of them have finished.
+func runConcurrently(n int, fn func(i int)) {
+	limit := runtime.NumCPU()
+	if limit < 1 {
+		limit = 1
+	}
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			sem <- struct{}{}
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			fn(i)
+		}()
+	}
+	wg.Wait()
+}
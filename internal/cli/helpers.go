@@ -1,14 +1,17 @@
 package cli
 
 import (
+	"bufio"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/mattn/go-isatty"
 	"github.com/sh0o0/gw/internal/gitx"
 	"github.com/sh0o0/gw/internal/worktree"
+	"github.com/spf13/cobra"
 )
 
 func primaryWorktreePath() (string, error) {
@@ -73,6 +76,8 @@ func navigateToRelativePath(worktreePath, rel string) error {
 type PostCreateOptions struct {
 	Verbose       bool
 	SymlinkSource string
+	// Conflict overrides gw.symlink.conflict when set.
+	Conflict worktree.ConflictPolicy
 }
 
 func createSymlinks(p string, opts PostCreateOptions) error {
@@ -84,17 +89,80 @@ func createSymlinks(p string, opts PostCreateOptions) error {
 			return err
 		}
 	}
-	symlinkOpts := worktree.SymlinkOptions{Verbose: opts.Verbose}
-	count, err := worktree.CreateSymlinksFromGitignored(root, p, symlinkOpts)
+	res, err := worktree.CreateSymlinksFromGitignored(root, p, symlinkOptions(opts.Verbose, opts.Conflict))
+	reportSymlinkConflicts(res)
 	if err != nil {
 		return err
 	}
-	if count > 0 {
-		out.Link("Created %d symlink(s)", count)
+	if res.Created > 0 {
+		out.Link("Created %d symlink(s)/copies", res.Created)
 	}
 	return nil
 }
 
+// conflictFlag is a pflag.Value validating --conflict at parse time.
+type conflictFlag struct {
+	policy *worktree.ConflictPolicy
+}
+
+func (f conflictFlag) String() string { return string(*f.policy) }
+func (f conflictFlag) Type() string   { return "policy" }
+
+func (f conflictFlag) Set(v string) error {
+	p, err := worktree.ParseConflictPolicy(v)
+	if err != nil {
+		return err
+	}
+	*f.policy = p
+	return nil
+}
+
+// addConflictFlag registers --conflict for commands that create symlinks.
+func addConflictFlag(cmd *cobra.Command, conflict *worktree.ConflictPolicy) {
+	cmd.Flags().Var(conflictFlag{conflict}, "conflict", "When a real file is in the way of a symlink: skip, backup, overwrite or prompt (default: gw.symlink.conflict, else backup)")
+}
+
+func symlinkOptions(verbose bool, conflict worktree.ConflictPolicy) worktree.SymlinkOptions {
+	return worktree.SymlinkOptions{Verbose: verbose, Conflict: conflict, Prompt: promptSymlinkConflict}
+}
+
+// reportSymlinkConflicts lists every destination that was skipped or backed up, and
+// how many copies were refreshed.
+func reportSymlinkConflicts(res worktree.SymlinkResult) {
+	if res.Refreshed > 0 {
+		out.Link("Refreshed %d copied file(s) whose source changed", res.Refreshed)
+	}
+	for _, b := range res.BackedUp {
+		out.Warn("Backed up %s to %s", out.Highlight(b.Path), out.Highlight(filepath.Base(b.BackupPath)))
+	}
+	for _, p := range res.Skipped {
+		out.Warn("Skipped %s (real file in the way)", out.Highlight(p))
+	}
+}
+
+// promptSymlinkConflict asks what to do with a real file in the way of a symlink.
+// It backs the file up when stdin is not a terminal.
+func promptSymlinkConflict(dst string) worktree.ConflictPolicy {
+	if !isatty.IsTerminal(os.Stdin.Fd()) {
+		return worktree.ConflictBackup
+	}
+	out.Warn("%s exists and is not a symlink", out.Highlight(dst))
+	fmt.Fprint(os.Stderr, "[b]ackup, [s]kip or [o]verwrite? [B/s/o]: ")
+	reader := bufio.NewReader(os.Stdin)
+	input, err := reader.ReadString('\n')
+	if err != nil {
+		return worktree.ConflictBackup
+	}
+	switch strings.TrimSpace(strings.ToLower(input)) {
+	case "s", "skip":
+		return worktree.ConflictSkip
+	case "o", "overwrite":
+		return worktree.ConflictOverwrite
+	default:
+		return worktree.ConflictBackup
+	}
+}
+
 func navigateToWorktree(p string) error {
 	rel, _ := relativePathFromGitRoot()
 	return navigateToRelativePath(p, rel)
@@ -106,10 +174,3 @@ func postCreateWorktree(p string, opts PostCreateOptions) error {
 	}
 	return navigateToWorktree(p)
 }
-
-func samePath(a, b string) bool {
-	if a == "" || b == "" {
-		return false
-	}
-	return filepath.Clean(a) == filepath.Clean(b)
-}
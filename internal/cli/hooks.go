@@ -3,28 +3,104 @@ package cli
 import (
 	"fmt"
 	"os"
+	"strings"
 
+	"github.com/mattn/go-isatty"
 	"github.com/sh0o0/gw/internal/hooks"
+	"github.com/sh0o0/gw/internal/jobs"
 )
 
-func runPostCreate(branch, worktreePath string, background bool) {
-	env := map[string]string{
-		"GW_HOOK_NAME": "post-create",
-		"GW_BRANCH":    branch,
-		"GW_PATH":      worktreePath,
-	}
-	opts := hooks.Options{Background: background}
-	ran, err := hooks.RunHook(worktreePath, "post-create", env, opts)
+// hookTarget describes the worktree a lifecycle hook is fired for.
+// Dir is where the commands run; it defaults to Path and must be set to an
+// existing directory (usually the primary worktree) when Path does not exist.
+type hookTarget struct {
+	Branch string
+	Path   string
+	Dir    string
+	// BaseRef, SymlinkSource, OldBranch and OldPath are exported to hooks when set
+	// (see hooks.Context).
+	BaseRef       string
+	SymlinkSource string
+	OldBranch     string
+	OldPath       string
+}
+
+// runHook fires the hook for event. pre-* hooks always run in the foreground.
+// A pre-* failure whose policy is abort (the default) is returned so the caller can
+// abort; other failures are reported as warnings. After a post-* hook the operation
+// has already happened, so abort only stops the hook's remaining commands.
+func runHook(event string, target hookTarget, background bool) error {
+	dir := target.Dir
+	if dir == "" {
+		dir = target.Path
+	}
+	env := hooks.Context{
+		Event:         event,
+		Branch:        target.Branch,
+		Path:          target.Path,
+		BaseRef:       target.BaseRef,
+		SymlinkSource: target.SymlinkSource,
+		OldBranch:     target.OldBranch,
+		OldPath:       target.OldPath,
+	}.Env(dir)
+	if hooks.IsPre(event) {
+		background = false
+	}
+	var jobIDs []string
+	opts := hooks.Options{
+		Background: background,
+		Branch:     target.Branch,
+		Started:    func(j *jobs.Job) { jobIDs = append(jobIDs, j.ID) },
+		Trust:      confirmHookScripts,
+	}
+	ran, err := hooks.RunHook(dir, event, env, opts)
 	if !ran {
-		return
+		return nil
 	}
 	if background {
-		fmt.Fprintf(os.Stderr, "post-create hook started (background), log: %s\n", hooks.LogFile(worktreePath, "post-create"))
-	} else {
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: post-create hook failed: %v\n", err)
-		} else {
-			fmt.Fprintln(os.Stderr, "post-create hook executed")
+		jobInfo := ""
+		if len(jobIDs) > 0 {
+			jobInfo = fmt.Sprintf(" as job %s", strings.Join(jobIDs, ", "))
 		}
+		fmt.Fprintf(os.Stderr, "%s hook started in background%s, log: %s\n", event, jobInfo, hooks.LogFile(dir, target.Branch, event))
+		return nil
+	}
+	if err != nil {
+		if hooks.IsPre(event) && hooks.Aborts(err) {
+			return err
+		}
+		out.Warn("%v", err)
+		return nil
+	}
+	fmt.Fprintf(os.Stderr, "%s hook executed\n", event)
+	return nil
+}
+
+// confirmHookScripts asks whether the repository's hook scripts may run. It refuses
+// without asking when stdin is not a terminal.
+func confirmHookScripts(scripts []string) bool {
+	if !isatty.IsTerminal(os.Stdin.Fd()) {
+		out.Warn("Skipping untrusted hook scripts in %s (run gw interactively to trust them)", hooks.ScriptsDir)
+		return false
+	}
+	out.Warn("This repository has new or changed hook scripts:")
+	for _, s := range scripts {
+		fmt.Fprintf(os.Stderr, "  %s\n", s)
+	}
+	return confirm("Trust and run them?")
+}
+
+func runPostCreate(target hookTarget, background bool) error {
+	return runHook(hooks.PostCreate, target, background)
+}
+
+// hookDir returns the directory hooks run in when the target worktree does not exist.
+func hookDir() string {
+	if p, err := primaryWorktreePath(); err == nil {
+		return p
+	}
+	if root, err := callerCWD(); err == nil {
+		return root
 	}
+	return ""
 }
@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sh0o0/gw/internal/jobs"
+	"github.com/spf13/cobra"
+)
+
+const jobWaitInterval = 200 * time.Millisecond
+
+func newJobsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "jobs",
+		Short: "List background jobs (hooks, rm --bg)",
+		Long: `List background jobs started by gw in this repository.
+
+Background hooks and "gw rm --bg" are recorded with their PID, command, start time,
+exit status and log path. Finished jobs are forgotten after a week.
+
+Examples:
+  gw jobs
+  gw jobs wait 3
+  gw jobs kill 3`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			list, err := jobs.List("")
+			if err != nil {
+				return err
+			}
+			if len(list) == 0 {
+				out.Info("No background jobs")
+				return nil
+			}
+			return writeJobsTable(cmd.OutOrStdout(), list, time.Now())
+		},
+	}
+	cmd.AddCommand(
+		newJobsWaitCmd(),
+		newJobsKillCmd(),
+	)
+	return cmd
+}
+
+func newJobsWaitCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "wait <id>...",
+		Short: "Block until jobs finish",
+		Long: `Block until the given jobs finish.
+
+Fails if any job exited with a non-zero status, timed out, was killed or was lost.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var failed []string
+			for _, id := range args {
+				job, err := jobs.Get("", id)
+				if err != nil {
+					return err
+				}
+				if job.Status == jobs.StatusRunning {
+					out.Working("Waiting for job %s: %s", job.ID, out.Highlight(job.Command))
+				}
+				job.Wait(jobWaitInterval)
+				if job.Status == jobs.StatusExited && job.ExitCode == 0 {
+					out.Success("Job %s finished", job.ID)
+					continue
+				}
+				out.Error("Job %s %s %s", job.ID, jobStatusText(job), out.Dim("(log: "+job.LogPath+")"))
+				failed = append(failed, job.ID)
+			}
+			if len(failed) > 0 {
+				return fmt.Errorf("%d job(s) failed", len(failed))
+			}
+			return nil
+		},
+	}
+}
+
+func newJobsKillCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "kill <id>...",
+		Short: "Stop running jobs",
+		Long:  `Stop running jobs by sending SIGTERM (then SIGKILL) to their whole process group.`,
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			for _, id := range args {
+				job, err := jobs.Get("", id)
+				if err != nil {
+					return err
+				}
+				if err := job.Kill(); err != nil {
+					return err
+				}
+				out.Success("Killed job %s: %s", job.ID, out.Highlight(job.Command))
+			}
+			return nil
+		},
+	}
+}
+
+func jobStatusText(j *jobs.Job) string {
+	switch j.Status {
+	case jobs.StatusExited:
+		return "exited " + strconv.Itoa(j.ExitCode)
+	default:
+		return string(j.Status)
+	}
+}
+
+func writeJobsTable(w io.Writer, list []*jobs.Job, now time.Time) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tSTATUS\tSTARTED\tPID\tCOMMAND\tLOG")
+	for _, j := range list {
+		log := j.LogPath
+		if log == "" {
+			log = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s ago\t%d\t%s\t%s\n",
+			j.ID, jobStatusText(j), formatAge(now.Sub(j.StartedAt)), j.PID, truncate(j.Command, 60), log)
+	}
+	return tw.Flush()
+}
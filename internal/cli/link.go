@@ -7,53 +7,309 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/bmatcuk/doublestar/v4"
 	"github.com/sh0o0/gw/internal/fsutil"
 	"github.com/sh0o0/gw/internal/gitx"
+	"github.com/sh0o0/gw/internal/worktree"
 	"github.com/spf13/cobra"
 )
 
+// linkOptions are the flags of gw link.
+type linkOptions struct {
+	Register     bool
+	Global       bool
+	AllWorktrees bool
+	DryRun       bool
+	Conflict     worktree.ConflictPolicy
+}
+
 func newLinkCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "link <path>",
-		Short: "Move file to base worktree and create symlink",
-		Args:  cobra.ExactArgs(1),
+	var opts linkOptions
+	cmd := &cobra.Command{
+		Use:   "link <path|glob>...",
+		Short: "Move files to the primary worktree and symlink them back",
+		Long: `Move files from the current worktree to the primary worktree and replace them
+with symlinks. Quoted globs (e.g. '**/.env*') are expanded from the current directory.
+
+In the primary worktree the files stay where they are; use --all-worktrees to link
+them into the other worktrees.
+
+With --register, a matching gw.symlink.include pattern is added (to the repository
+config, or the global one with --global) so future worktrees get the files too.
+
+Examples:
+  gw link .env
+  gw link '**/.env*' --register --all-worktrees
+  gw link .vscode --register --global --dry-run`,
+		Args: cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			p, err := fsutil.ResolveAbs(args[0])
-			if err != nil {
-				return err
+			if opts.Global && !opts.Register {
+				return errors.New("--global requires --register")
 			}
-			if _, err := os.Lstat(p); err != nil {
-				return fmt.Errorf("file not found: %s", p)
+			return linkPaths(args, opts)
+		},
+	}
+	cmd.Flags().BoolVar(&opts.Register, "register", false, "Add a gw.symlink.include pattern for the paths")
+	cmd.Flags().BoolVar(&opts.Global, "global", false, "With --register, add the pattern to the global git config")
+	cmd.Flags().BoolVar(&opts.AllWorktrees, "all-worktrees", false, "Also create the links in every other worktree")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "Show what would be done without changing anything")
+	addConflictFlag(cmd, &opts.Conflict)
+	return cmd
+}
+
+// linkTarget is a path to link, relative to the worktree root.
+type linkTarget struct {
+	rel   string
+	isDir bool
+}
+
+func linkPaths(args []string, opts linkOptions) error {
+	cwd, err := callerCWD()
+	if err != nil {
+		return err
+	}
+	root, err := gitx.Root(cwd)
+	if err != nil {
+		return err
+	}
+	primary, err := primaryWorktreePath()
+	if err != nil {
+		return err
+	}
+	inPrimary := fsutil.SamePath(root, primary)
+	if inPrimary && !opts.AllWorktrees && !opts.Register {
+		return errors.New("you are in the primary worktree; use --all-worktrees or --register")
+	}
+
+	targets, patterns, err := expandLinkArgs(cwd, root, args)
+	if err != nil {
+		return err
+	}
+	targets = withoutNestedTargets(targets)
+	var others []string
+	if opts.AllWorktrees {
+		wts, err := gitx.ListWorktrees(primary)
+		if err != nil {
+			return err
+		}
+		for _, wt := range wts {
+			if !fsutil.SamePath(wt.Path, primary) && !fsutil.SamePath(wt.Path, root) && worktreeExists(wt) {
+				others = append(others, wt.Path)
 			}
-			root, err := gitx.Root("")
+		}
+	}
+	symOpts := symlinkOptions(false, opts.Conflict)
+	symOpts.Relative = worktree.RelativeFromConfig(primary)
+
+	var moves map[string]bool
+	if !inPrimary {
+		// Check every path before moving any, so a conflict leaves nothing half done.
+		if moves, err = checkLinkMoves(root, primary, targets); err != nil {
+			return err
+		}
+	}
+	for _, t := range targets {
+		p := filepath.Join(root, t.rel)
+		src := filepath.Join(primary, t.rel)
+		if !inPrimary {
+			switch {
+			case !moves[t.rel]:
+				out.Info("Already a symlink: %s", out.Highlight(t.rel))
+			case opts.DryRun:
+				fmt.Printf("Would link: %s -> %s\n", p, src)
+			default:
+				if err := fsutil.EnsureDir(filepath.Dir(src)); err != nil {
+					return err
+				}
+				if err := os.Rename(p, src); err != nil {
+					return err
+				}
+				var res worktree.SymlinkResult
+				if err := worktree.Link(src, p, worktree.SymlinkOptions{Relative: symOpts.Relative}, &res); err != nil {
+					return err
+				}
+				fmt.Printf("Linked: %s -> %s\n", p, src)
+			}
+		}
+		for _, wt := range others {
+			dst := filepath.Join(wt, t.rel)
+			if opts.DryRun {
+				fmt.Printf("Would link: %s -> %s\n", dst, src)
+				continue
+			}
+			res := worktree.SymlinkResult{}
+			err := worktree.Link(src, dst, symOpts, &res)
+			reportSymlinkConflicts(res)
 			if err != nil {
 				return err
 			}
-			primary, _ := primaryWorktreePath()
-			if root == primary {
-				return errors.New("you are in the primary worktree; nothing to link")
-			}
-			if !strings.HasPrefix(p, root+string(os.PathSeparator)) {
-				return fmt.Errorf("path must be within current worktree: %s", root)
+			if res.Created > 0 {
+				fmt.Printf("Linked: %s -> %s\n", dst, src)
 			}
-			rel, _ := filepath.Rel(root, p)
-			dst := filepath.Join(primary, rel)
-			if _, err := os.Lstat(dst); err == nil {
-				return fmt.Errorf("destination already exists: %s", dst)
+		}
+		if !isIgnored(primary, t.rel) {
+			out.Warn("%s is not gitignored; new worktrees only get ignored files", out.Highlight(t.rel))
+		}
+	}
+
+	if opts.Register {
+		return registerIncludePatterns(primary, patterns, opts)
+	}
+	return nil
+}
+
+// checkLinkMoves reports which targets are to be moved from root to primary, and
+// fails if any of them is missing or already exists in primary. Symlinks are already
+// linked and stay put.
+func checkLinkMoves(root, primary string, targets []linkTarget) (map[string]bool, error) {
+	moves := map[string]bool{}
+	for _, t := range targets {
+		p := filepath.Join(root, t.rel)
+		fi, err := os.Lstat(p)
+		if err != nil {
+			return nil, fmt.Errorf("file not found: %s", p)
+		}
+		if fi.Mode()&os.ModeSymlink != 0 {
+			continue
+		}
+		src := filepath.Join(primary, t.rel)
+		if _, err := os.Lstat(src); err == nil {
+			return nil, fmt.Errorf("destination already exists: %s", src)
+		}
+		moves[t.rel] = true
+	}
+	return moves, nil
+}
+
+// withoutNestedTargets drops targets inside another target's directory; they are
+// moved and linked along with it.
+func withoutNestedTargets(targets []linkTarget) []linkTarget {
+	var res []linkTarget
+	for _, t := range targets {
+		nested := false
+		for _, d := range targets {
+			if d.isDir && strings.HasPrefix(t.rel, d.rel+string(filepath.Separator)) {
+				nested = true
+				break
 			}
-			if err := fsutil.EnsureDir(filepath.Dir(dst)); err != nil {
-				return err
+		}
+		if !nested {
+			res = append(res, t)
+		}
+	}
+	return res
+}
+
+func isIgnored(root, rel string) bool {
+	_, err := gitx.Cmd(root, "check-ignore", "-q", "--no-index", rel)
+	return err == nil
+}
+
+// expandLinkArgs resolves args against cwd. Arguments that do not exist and contain
+// glob characters are expanded. It returns the paths to link, relative to root, and
+// the gw.symlink.include patterns that match them.
+func expandLinkArgs(cwd, root string, args []string) ([]linkTarget, []string, error) {
+	var targets []linkTarget
+	var patterns []string
+	seen := map[string]bool{}
+	add := func(abs string) error {
+		if !strings.HasPrefix(abs, root+string(os.PathSeparator)) {
+			return fmt.Errorf("path must be within current worktree: %s", root)
+		}
+		rel, _ := filepath.Rel(root, abs)
+		if seen[rel] {
+			return nil
+		}
+		seen[rel] = true
+		fi, err := os.Lstat(abs)
+		if err != nil {
+			return fmt.Errorf("file not found: %s", abs)
+		}
+		targets = append(targets, linkTarget{rel: rel, isDir: fi.IsDir()})
+		return nil
+	}
+	for _, arg := range args {
+		abs := arg
+		if !filepath.IsAbs(abs) {
+			abs = filepath.Join(cwd, arg)
+		}
+		abs = filepath.Clean(abs)
+		if _, err := os.Lstat(abs); err != nil && !filepath.IsAbs(arg) && strings.ContainsAny(arg, "*?[{") {
+			matches, err := doublestar.Glob(os.DirFS(cwd), filepath.ToSlash(arg))
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid pattern %q: %w", arg, err)
 			}
-			if err := os.Rename(p, dst); err != nil {
-				return err
+			if len(matches) == 0 {
+				return nil, nil, fmt.Errorf("no files match %s", arg)
 			}
-			if err := os.Symlink(dst, p); err != nil {
-				return err
+			for _, m := range matches {
+				if err := add(filepath.Join(cwd, m)); err != nil {
+					return nil, nil, err
+				}
 			}
-			fmt.Printf("Linked: %s -> %s\n", p, dst)
-			return nil
-		},
+			patterns = append(patterns, globIncludePattern(cwd, root, arg))
+			continue
+		}
+		if err := add(abs); err != nil {
+			return nil, nil, err
+		}
+		t := targets[len(targets)-1]
+		pattern := "/" + filepath.ToSlash(t.rel)
+		if t.isDir {
+			pattern += "/**"
+		}
+		patterns = append(patterns, pattern)
+	}
+	return targets, patterns, nil
+}
+
+// globIncludePattern turns a glob relative to cwd into a pattern matched against
+// "/"+path from the worktree root, as gw.symlink.include is.
+func globIncludePattern(cwd, root, glob string) string {
+	glob = filepath.ToSlash(glob)
+	rel, _ := filepath.Rel(root, cwd)
+	if rel == "." {
+		if strings.HasPrefix(glob, "**/") {
+			return glob
+		}
+		return "/" + glob
+	}
+	return "/" + filepath.ToSlash(rel) + "/" + glob
+}
+
+// registerIncludePatterns adds the patterns not configured yet to gw.symlink.include.
+func registerIncludePatterns(cwd string, patterns []string, opts linkOptions) error {
+	// Entries may carry a mode, as in copy:/.env, so compare their globs.
+	have := map[string]bool{}
+	for _, p := range worktree.IncludePatterns(cwd) {
+		have[p.Glob] = true
+	}
+	scope := "local"
+	if opts.Global {
+		scope = "global"
+	}
+	for _, p := range patterns {
+		if have[p] {
+			out.Info("Pattern already registered: %s", out.Highlight(p))
+			continue
+		}
+		have[p] = true
+		if opts.DryRun {
+			fmt.Printf("Would register (%s): gw.symlink.include %s\n", scope, p)
+			continue
+		}
+		var err error
+		if opts.Global {
+			err = gitx.ConfigAddGlobal("gw.symlink.include", p)
+		} else {
+			err = gitx.ConfigAdd(cwd, "gw.symlink.include", p)
+		}
+		if err != nil {
+			return err
+		}
+		out.Success("Registered (%s): gw.symlink.include %s", scope, out.Highlight(p))
 	}
+	return nil
 }
 
 func newUnlinkCmd() *cobra.Command {
@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func setupLinkRepo(t *testing.T) (repo, wtA, wtB string) {
+	t.Helper()
+	home, _ := filepath.EvalSymlinks(t.TempDir())
+	t.Setenv("HOME", home)
+	repo = filepath.Join(home, "repo")
+	initTestRepo(t, repo)
+	if err := os.WriteFile(filepath.Join(repo, ".gitignore"), []byte(".env*\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, repo, "add", ".gitignore")
+	runGit(t, repo, "commit", "-m", "ignore")
+	wtA = filepath.Join(home, "a")
+	wtB = filepath.Join(home, "b")
+	runGit(t, repo, "worktree", "add", "-b", "a", wtA)
+	runGit(t, repo, "worktree", "add", "-b", "b", wtB)
+	return repo, wtA, wtB
+}
+
+func TestLinkPaths_shouldLinkIntoAllWorktrees_andRegisterPattern(t *testing.T) {
+	repo, wtA, wtB := setupLinkRepo(t)
+	if err := os.WriteFile(filepath.Join(wtA, ".env"), []byte("A=1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GW_CALLER_CWD", wtA)
+
+	if err := linkPaths([]string{".env"}, linkOptions{Register: true, AllWorktrees: true}); err != nil {
+		t.Fatalf("linkPaths: %v", err)
+	}
+
+	if fi, err := os.Lstat(filepath.Join(repo, ".env")); err != nil || !fi.Mode().IsRegular() {
+		t.Fatalf("expected file moved to primary: %v", err)
+	}
+	for _, wt := range []string{wtA, wtB} {
+		if data, err := os.ReadFile(filepath.Join(wt, ".env")); err != nil || string(data) != "A=1" {
+			t.Fatalf("expected link in %s, got %q err=%v", wt, data, err)
+		}
+	}
+	patterns := runGitOutput(t, repo, "config", "--get-all", "gw.symlink.include")
+	if strings.TrimSpace(patterns) != "/.env" {
+		t.Fatalf("unexpected registered patterns: %q", patterns)
+	}
+}
+
+func TestLinkPaths_shouldChangeNothing_whenDryRunWithGlob(t *testing.T) {
+	repo, wtA, _ := setupLinkRepo(t)
+	for _, name := range []string{".env", ".env.local"} {
+		if err := os.WriteFile(filepath.Join(wtA, name), []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("GW_CALLER_CWD", wtA)
+
+	if err := linkPaths([]string{".env*"}, linkOptions{Register: true, AllWorktrees: true, DryRun: true}); err != nil {
+		t.Fatalf("linkPaths: %v", err)
+	}
+	for _, name := range []string{".env", ".env.local"} {
+		if fi, err := os.Lstat(filepath.Join(wtA, name)); err != nil || !fi.Mode().IsRegular() {
+			t.Fatalf("dry run must not move %s: %v", name, err)
+		}
+		if _, err := os.Lstat(filepath.Join(repo, name)); err == nil {
+			t.Fatalf("dry run must not create %s in primary", name)
+		}
+	}
+	cmd := exec.Command("git", "config", "--get-all", "gw.symlink.include")
+	cmd.Dir = repo
+	if out, _ := cmd.Output(); len(out) != 0 {
+		t.Fatalf("dry run must not register patterns, got %q", out)
+	}
+}
+
+func TestLinkPaths_shouldMoveNothing_whenAnyDestinationExists(t *testing.T) {
+	repo, wtA, _ := setupLinkRepo(t)
+	for _, name := range []string{".env", ".env.local"} {
+		if err := os.WriteFile(filepath.Join(wtA, name), []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(repo, ".env.local"), []byte("primary"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GW_CALLER_CWD", wtA)
+
+	if err := linkPaths([]string{".env", ".env.local"}, linkOptions{Register: true}); err == nil {
+		t.Fatal("expected linkPaths to fail on the existing destination")
+	}
+	if fi, err := os.Lstat(filepath.Join(wtA, ".env")); err != nil || !fi.Mode().IsRegular() {
+		t.Fatalf("expected .env to stay in place: %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(repo, ".env")); err == nil {
+		t.Fatal("expected .env not to be moved to primary")
+	}
+}
+
+func TestLinkPaths_shouldNotRegisterAgain_whenPatternHasMode(t *testing.T) {
+	repo, wtA, _ := setupLinkRepo(t)
+	if err := os.WriteFile(filepath.Join(wtA, ".env"), []byte("A=1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, repo, "config", "--add", "gw.symlink.include", "copy:/.env")
+	t.Setenv("GW_CALLER_CWD", wtA)
+
+	if err := linkPaths([]string{".env"}, linkOptions{Register: true}); err != nil {
+		t.Fatalf("linkPaths: %v", err)
+	}
+	patterns := runGitOutput(t, repo, "config", "--get-all", "gw.symlink.include")
+	if strings.TrimSpace(patterns) != "copy:/.env" {
+		t.Fatalf("unexpected registered patterns: %q", patterns)
+	}
+}
+
+func TestGlobIncludePattern_shouldAnchorToWorktreeRoot(t *testing.T) {
+	root := "/wt"
+	cases := []struct{ cwd, glob, want string }{
+		{"/wt", "**/.env*", "**/.env*"},
+		{"/wt", ".env*", "/.env*"},
+		{"/wt/apps/web", ".env*", "/apps/web/.env*"},
+	}
+	for _, c := range cases {
+		if got := globIncludePattern(c.cwd, root, c.glob); got != c.want {
+			t.Errorf("globIncludePattern(%q, %q) = %q, want %q", c.cwd, c.glob, got, c.want)
+		}
+	}
+}
@@ -1,38 +1,323 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
 
+	"github.com/sh0o0/gw/internal/fsutil"
 	"github.com/sh0o0/gw/internal/gitx"
 	"github.com/spf13/cobra"
 )
 
 func newListCmd() *cobra.Command {
-	return &cobra.Command{
+	var asJSON bool
+	var format string
+	var long bool
+	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "List all worktrees",
+		Long: `List all worktrees.
+
+Without flags the output of 'git worktree list' is printed as is.
+Use --json or --format for machine-readable output; see the README for the schema.
+Use --long for a table with dirty files, ahead/behind counts, last commit, stashes and PR status.
+
+Examples:
+  gw list --long
+  gw list --json
+  gw list --format '{{.Branch}}\t{{.Path}}'
+  gw list --format '{{if .Current}}* {{end}}{{.Branch}} {{join .Assignees ","}}'`,
+		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			out, err := gitx.Cmd("", "worktree", "list")
+			if long {
+				rows, err := loadLongRows()
+				if err != nil {
+					return err
+				}
+				return writeLongTable(cmd.OutOrStdout(), rows, time.Now())
+			}
+			if !asJSON && format == "" {
+				out, err := gitx.Cmd("", "worktree", "list")
+				if err != nil {
+					return err
+				}
+				fmt.Print(out)
+				return nil
+			}
+			entries, err := loadListEntries()
 			if err != nil {
 				return err
 			}
-			fmt.Print(out)
-			return nil
+			if asJSON {
+				return writeListJSON(cmd.OutOrStdout(), entries)
+			}
+			return writeListTemplate(cmd.OutOrStdout(), format, entries)
 		},
 	}
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Output worktrees as JSON")
+	cmd.Flags().StringVar(&format, "format", "", "Format each worktree using a Go template")
+	cmd.Flags().BoolVarP(&long, "long", "l", false, "Show a detailed status table")
+	cmd.MarkFlagsMutuallyExclusive("json", "format", "long")
+	return cmd
 }
 
-func newCleanCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "clean",
-		Short: "Clean up stale worktree references",
-		RunE: func(cmd *cobra.Command, args []string) error {
-			out, err := gitx.Cmd("", "worktree", "prune")
-			if err != nil {
-				return err
+// listEntry is the stable, documented shape of a worktree in `gw list --json`
+// and the data passed to `gw list --format` templates.
+type listEntry struct {
+	Path       string   `json:"path"`
+	Branch     string   `json:"branch"`
+	Detached   bool     `json:"detached"`
+	Primary    bool     `json:"primary"`
+	Current    bool     `json:"current"`
+	Status     string   `json:"status"`
+	Assignees  []string `json:"assignees"`
+	Locked     bool     `json:"locked"`
+	LockReason string   `json:"lockReason,omitempty"`
+}
+
+func loadListEntries() ([]*listEntry, error) {
+	wts, err := gitx.ListWorktrees("")
+	if err != nil {
+		return nil, err
+	}
+	current, _ := gitx.CurrentWorktreePath("")
+	primaryPath, _ := primaryWorktreePath()
+
+	entries := make([]*listEntry, 0, len(wts))
+	for _, wt := range wts {
+		detached := wt.Branch == "" || wt.Branch == "HEAD"
+		branch := wt.Branch
+		if detached {
+			branch = ""
+		}
+		entries = append(entries, &listEntry{
+			Path:       wt.Path,
+			Branch:     branch,
+			Detached:   detached,
+			Primary:    fsutil.SamePath(wt.Path, primaryPath),
+			Current:    fsutil.SamePath(wt.Path, current),
+			Assignees:  []string{},
+			Locked:     wt.Locked,
+			LockReason: wt.LockReason,
+		})
+	}
+
+	root, err := gitx.Root("")
+	if err != nil {
+		root = ""
+	}
+	resolver := gitx.NewBranchStatusResolver(root)
+	runConcurrently(len(entries), func(i int) {
+		e := entries[i]
+		if e.Primary || e.Detached {
+			return
+		}
+		info := resolver.StatusInfo(e.Path, e.Branch)
+		e.Status = info.Status.String()
+		if len(info.Assignees) > 0 {
+			e.Assignees = info.Assignees
+		}
+	})
+	return entries, nil
+}
+
+func writeListJSON(w io.Writer, entries []*listEntry) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(entries)
+}
+
+func writeListTemplate(w io.Writer, format string, entries []*listEntry) error {
+	tmpl, err := template.New("list").Funcs(template.FuncMap{
+		"join": strings.Join,
+	}).Parse(unescapeFormat(format))
+	if err != nil {
+		return fmt.Errorf("invalid format: %w", err)
+	}
+	for _, e := range entries {
+		if err := tmpl.Execute(w, e); err != nil {
+			return fmt.Errorf("invalid format: %w", err)
+		}
+		if _, err := io.WriteString(w, "\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unescapeFormat expands the \t and \n escapes that shells pass through literally.
+func unescapeFormat(s string) string {
+	return strings.NewReplacer(`\t`, "\t", `\n`, "\n").Replace(s)
+}
+
+// longRow holds the per-worktree details shown by `gw list --long`.
+type longRow struct {
+	entry          *listEntry
+	dirty          int
+	upstream       string
+	upstreamAhead  int
+	upstreamBehind int
+	baseAhead      int
+	baseBehind     int
+	hasBase        bool
+	commitTime     time.Time
+	commitSubject  string
+	stashes        int
+}
+
+func loadLongRows() ([]*longRow, error) {
+	entries, err := loadListEntries()
+	if err != nil {
+		return nil, err
+	}
+	root, err := gitx.Root("")
+	if err != nil {
+		root = ""
+	}
+	baseRef := gitx.NewBranchStatusResolver(root).BaseRef()
+	stashes, _ := gitx.StashCounts(root)
+
+	rows := make([]*longRow, len(entries))
+	runConcurrently(len(entries), func(i int) {
+		e := entries[i]
+		row := &longRow{entry: e, stashes: stashes[e.Branch]}
+		row.dirty, _ = gitx.DirtyCount(e.Path)
+		if up := gitx.Upstream(e.Path); up != "" {
+			if a, b, err := gitx.AheadBehind(e.Path, up); err == nil {
+				row.upstream = up
+				row.upstreamAhead, row.upstreamBehind = a, b
 			}
-			fmt.Print(out)
-			return nil
-		},
+		}
+		if baseRef != "" && !e.Primary {
+			if a, b, err := gitx.AheadBehind(e.Path, baseRef); err == nil {
+				row.hasBase = true
+				row.baseAhead, row.baseBehind = a, b
+			}
+		}
+		row.commitTime, row.commitSubject, _ = gitx.LastCommit(e.Path)
+		rows[i] = row
+	})
+	return rows, nil
+}
+
+const longSubjectWidth = 40
+
+func writeLongTable(w io.Writer, rows []*longRow, now time.Time) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "  BRANCH\tDIRTY\tUPSTREAM\tBASE\tAGE\tLAST COMMIT\tSTASH\tSTATUS\tLOCK\tASSIGNEES")
+	for _, r := range rows {
+		marker := "  "
+		if r.entry.Primary {
+			marker = "★ "
+		} else if r.entry.Current {
+			marker = "* "
+		}
+		branch := r.entry.Branch
+		if r.entry.Detached {
+			branch = "(detached)"
+		}
+		upstream := "-"
+		if r.upstream != "" {
+			upstream = formatAheadBehind(r.upstreamAhead, r.upstreamBehind)
+		}
+		base := "-"
+		if r.hasBase {
+			base = formatAheadBehind(r.baseAhead, r.baseBehind)
+		}
+		age := "-"
+		if !r.commitTime.IsZero() {
+			age = formatAge(now.Sub(r.commitTime))
+		}
+		lock := "-"
+		if r.entry.Locked {
+			lock = "locked"
+			if r.entry.LockReason != "" {
+				lock += ": " + truncate(r.entry.LockReason, longSubjectWidth)
+			}
+		}
+		fmt.Fprintf(tw, "%s%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			marker,
+			branch,
+			formatCount(r.dirty),
+			upstream,
+			base,
+			age,
+			truncate(r.commitSubject, longSubjectWidth),
+			formatCount(r.stashes),
+			gitx.BranchStatus(r.entry.Status).Display(),
+			lock,
+			strings.Join(r.entry.Assignees, ","),
+		)
+	}
+	return tw.Flush()
+}
+
+func formatAheadBehind(ahead, behind int) string {
+	if ahead == 0 && behind == 0 {
+		return "="
+	}
+	return fmt.Sprintf("↑%d ↓%d", ahead, behind)
+}
+
+func formatCount(n int) string {
+	if n == 0 {
+		return "-"
+	}
+	return fmt.Sprint(n)
+}
+
+// formatAge renders a duration in the largest whole unit: 45s, 12m, 3h, 5d, 2w, 4mo, 1y.
+func formatAge(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	case d < 14*24*time.Hour:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	case d < 60*24*time.Hour:
+		return fmt.Sprintf("%dw", int(d.Hours()/(24*7)))
+	case d < 365*24*time.Hour:
+		return fmt.Sprintf("%dmo", int(d.Hours()/(24*30)))
+	default:
+		return fmt.Sprintf("%dy", int(d.Hours()/(24*365)))
+	}
+}
+
+// parseAge parses an age such as 30d, 2w, 12h or 90m. Days and weeks are accepted in
+// addition to the units of time.ParseDuration.
+func parseAge(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			v, err := strconv.Atoi(n)
+			if err != nil || v < 0 {
+				return 0, fmt.Errorf("invalid age %q (e.g. 30d, 2w, 12h)", s)
+			}
+			return time.Duration(v) * unit, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q (e.g. 30d, 2w, 12h)", s)
+	}
+	return d, nil
+}
+
+func truncate(s string, width int) string {
+	r := []rune(s)
+	if len(r) <= width {
+		return s
 	}
+	return string(r[:width-1]) + "…"
 }
@@ -0,0 +1,162 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sh0o0/gw/internal/worktree"
+)
+
+func TestLoadListEntries_shouldMarkPrimaryAndCurrent_whenWorktreesExist(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	repo := filepath.Join(home, "repo")
+	initTestRepo(t, repo)
+	t.Setenv("GW_CALLER_CWD", repo)
+
+	branch := "feature/list"
+	wtPath, err := worktree.ComputeWorktreePath(repo, branch)
+	if err != nil {
+		t.Fatalf("compute worktree path: %v", err)
+	}
+	runGit(t, repo, "worktree", "add", wtPath, "-b", branch)
+	runGit(t, repo, "worktree", "lock", "--reason", "agent running", wtPath)
+
+	entries, err := loadListEntries()
+	if err != nil {
+		t.Fatalf("loadListEntries: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if !entries[0].Primary || entries[0].Branch != "main" {
+		t.Fatalf("expected first entry to be primary main, got %+v", entries[0])
+	}
+	if entries[1].Primary || entries[1].Branch != branch || entries[1].Path != wtPath {
+		t.Fatalf("unexpected second entry: %+v", entries[1])
+	}
+	if entries[1].Status == "" {
+		t.Fatalf("expected status to be resolved for %s", branch)
+	}
+	if !entries[1].Locked || entries[1].LockReason != "agent running" || entries[0].Locked {
+		t.Fatalf("expected only %s to be locked, got %+v and %+v", branch, entries[0], entries[1])
+	}
+
+	var buf bytes.Buffer
+	if err := writeListJSON(&buf, entries); err != nil {
+		t.Fatalf("writeListJSON: %v", err)
+	}
+	var decoded []map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("decode json: %v", err)
+	}
+	for _, key := range []string{"path", "branch", "detached", "primary", "current", "status", "assignees", "locked", "lockReason"} {
+		if _, ok := decoded[1][key]; !ok {
+			t.Fatalf("expected key %q in json output: %s", key, buf.String())
+		}
+	}
+}
+
+func TestWriteListTemplate_shouldRenderEachEntry(t *testing.T) {
+	entries := []*listEntry{
+		{Path: "/a", Branch: "main", Primary: true, Assignees: []string{}},
+		{Path: "/b", Branch: "feature", Status: "opened", Assignees: []string{"alice", "bob"}},
+	}
+	var buf bytes.Buffer
+	if err := writeListTemplate(&buf, `{{.Branch}}\t{{.Status}}\t{{join .Assignees ","}}`, entries); err != nil {
+		t.Fatalf("writeListTemplate: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || lines[0] != "main\t\t" || lines[1] != "feature\topened\talice,bob" {
+		t.Fatalf("unexpected output: %q", buf.String())
+	}
+}
+
+func TestWriteListTemplate_shouldReturnError_whenTemplateInvalid(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeListTemplate(&buf, "{{.Branch", nil); err == nil {
+		t.Fatal("expected error for invalid template")
+	}
+}
+
+func TestFormatAge_shouldUseLargestUnit(t *testing.T) {
+	cases := []struct {
+		d    time.Duration
+		want string
+	}{
+		{30 * time.Second, "30s"},
+		{5 * time.Minute, "5m"},
+		{3 * time.Hour, "3h"},
+		{2 * 24 * time.Hour, "2d"},
+		{21 * 24 * time.Hour, "3w"},
+		{90 * 24 * time.Hour, "3mo"},
+		{800 * 24 * time.Hour, "2y"},
+	}
+	for _, c := range cases {
+		if got := formatAge(c.d); got != c.want {
+			t.Fatalf("formatAge(%v) = %q, want %q", c.d, got, c.want)
+		}
+	}
+}
+
+func TestParseAge_shouldAcceptDaysWeeksAndDurations(t *testing.T) {
+	cases := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"30d", 30 * 24 * time.Hour},
+		{"2w", 14 * 24 * time.Hour},
+		{"12h", 12 * time.Hour},
+		{"90m", 90 * time.Minute},
+	}
+	for _, c := range cases {
+		got, err := parseAge(c.in)
+		if err != nil || got != c.want {
+			t.Fatalf("parseAge(%q) = %v, %v; want %v", c.in, got, err, c.want)
+		}
+	}
+	for _, in := range []string{"", "d", "-3d", "soon"} {
+		if _, err := parseAge(in); err == nil {
+			t.Fatalf("parseAge(%q): expected error", in)
+		}
+	}
+}
+
+func TestWriteLongTable_shouldRenderRowPerWorktree(t *testing.T) {
+	now := time.Now()
+	rows := []*longRow{
+		{entry: &listEntry{Branch: "main", Primary: true}, commitTime: now.Add(-2 * time.Hour), commitSubject: "init"},
+		{
+			entry:         &listEntry{Branch: "feature", Status: "opened", Assignees: []string{"alice"}},
+			dirty:         3,
+			hasBase:       true,
+			baseAhead:     2,
+			commitTime:    now.Add(-3 * 24 * time.Hour),
+			commitSubject: "feat: work",
+			stashes:       1,
+		},
+	}
+	var buf bytes.Buffer
+	if err := writeLongTable(&buf, rows, now); err != nil {
+		t.Fatalf("writeLongTable: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected header and 2 rows, got %q", buf.String())
+	}
+	for _, want := range []string{"★ main", "2h", "init"} {
+		if !strings.Contains(lines[1], want) {
+			t.Fatalf("expected %q in primary row: %q", want, lines[1])
+		}
+	}
+	for _, want := range []string{"feature", "3", "↑2 ↓0", "3d", "feat: work", "OPENED", "alice"} {
+		if !strings.Contains(lines[2], want) {
+			t.Fatalf("expected %q in feature row: %q", want, lines[2])
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/sh0o0/gw/internal/fsutil"
+	"github.com/sh0o0/gw/internal/gitx"
+	"github.com/spf13/cobra"
+)
+
+func newLockCmd() *cobra.Command {
+	var reason string
+	cmd := &cobra.Command{
+		Use:   "lock [branch]",
+		Short: "Lock a worktree so it cannot be removed or pruned",
+		Long: `Lock a worktree with git worktree lock. Locked worktrees are not removed by
+gw rm, gw gc or the TUI, and git worktree prune keeps them even when their
+directory is missing, e.g. on an unmounted removable drive.
+
+Without a branch the current worktree is locked.
+
+Examples:
+  gw lock feature/agent --reason "agent run in progress"
+  gw lock --reason "on usb drive"`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			wt, err := worktreeForLock(args)
+			if err != nil {
+				return err
+			}
+			if wt.Locked {
+				return fmt.Errorf("%s is already locked%s", wt.Path, lockReasonSuffix(wt.LockReason))
+			}
+			if err := gitx.LockWorktree("", wt.Path, reason); err != nil {
+				return err
+			}
+			out.Success("Locked %s%s", out.Highlight(wt.Path), lockReasonSuffix(reason))
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&reason, "reason", "", "Why the worktree is locked")
+	return cmd
+}
+
+func newUnlockCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "unlock [branch]",
+		Short: "Unlock a worktree locked with gw lock",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			wt, err := worktreeForLock(args)
+			if err != nil {
+				return err
+			}
+			if !wt.Locked {
+				out.Info("%s is not locked", out.Highlight(wt.Path))
+				return nil
+			}
+			if err := gitx.UnlockWorktree("", wt.Path); err != nil {
+				return err
+			}
+			out.Success("Unlocked %s", out.Highlight(wt.Path))
+			return nil
+		},
+	}
+}
+
+// worktreeForLock returns the worktree of the branch in args, or the current worktree.
+func worktreeForLock(args []string) (gitx.Worktree, error) {
+	wts, err := gitx.ListWorktrees("")
+	if err != nil {
+		return gitx.Worktree{}, err
+	}
+	if len(args) == 0 {
+		current, err := gitx.CurrentWorktreePath("")
+		if err != nil {
+			return gitx.Worktree{}, err
+		}
+		for _, wt := range wts {
+			if fsutil.SamePath(wt.Path, current) {
+				return wt, nil
+			}
+		}
+		return gitx.Worktree{}, errors.New("not in a worktree")
+	}
+	for _, wt := range wts {
+		if wt.Branch == args[0] {
+			return wt, nil
+		}
+	}
+	return gitx.Worktree{}, fmt.Errorf("no worktree found for branch: %s", args[0])
+}
+
+// checkLocked refuses to remove a locked worktree.
+func checkLocked(path string) error {
+	wts, err := gitx.ListWorktrees("")
+	if err != nil {
+		return nil
+	}
+	for _, wt := range wts {
+		if fsutil.SamePath(wt.Path, path) && wt.Locked {
+			return fmt.Errorf("%s is locked%s; run gw unlock first", path, lockReasonSuffix(wt.LockReason))
+		}
+	}
+	return nil
+}
+
+func lockReasonSuffix(reason string) string {
+	if reason == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (%s)", reason)
+}
@@ -0,0 +1,190 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sh0o0/gw/internal/gitx"
+	"github.com/sh0o0/gw/internal/state"
+	"github.com/spf13/cobra"
+
+	fuzzyfinder "github.com/ktr0731/go-fuzzyfinder"
+)
+
+const logFollowInterval = 300 * time.Millisecond
+
+func newLogsCmd() *cobra.Command {
+	var hookName string
+	var follow bool
+	var opts fuzzyDisplayOptions
+	cmd := &cobra.Command{
+		Use:   "logs [branch]",
+		Short: "Show hook logs of a worktree",
+		Long: `Show hook logs of a worktree.
+
+Logs are stored in $XDG_STATE_HOME/gw/<repo>/<branch>/<hook>.log
+(~/.local/state/gw/... when XDG_STATE_HOME is unset).
+If branch is not specified, an interactive fuzzy finder will be shown to select the worktree.
+
+Examples:
+  gw logs
+  gw logs feature/foo --hook post-create
+  gw logs feature/foo --follow`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			branch := ""
+			if len(args) == 1 {
+				branch = args[0]
+			} else {
+				selected, err := selectLogsBranch(opts)
+				if err != nil {
+					return err
+				}
+				branch = selected
+			}
+			files, err := hookLogFiles(branch, hookName)
+			if err != nil {
+				return err
+			}
+			if follow {
+				return followLog(cmd.OutOrStdout(), files[len(files)-1])
+			}
+			return printLogs(cmd.OutOrStdout(), files)
+		},
+	}
+	cmd.Flags().StringVar(&hookName, "hook", "", "Only show the log of this hook (e.g. post-create)")
+	cmd.Flags().BoolVarP(&follow, "follow", "f", false, "Keep printing new output (most recent log unless --hook is given)")
+	cmd.Flags().BoolVar(&opts.showPath, "show-path", false, "display worktree path in fuzzy finder")
+	return cmd
+}
+
+func selectLogsBranch(opts fuzzyDisplayOptions) (string, error) {
+	wts, err := gitx.ListWorktrees("")
+	if err != nil {
+		return "", err
+	}
+	primaryPath, _ := primaryWorktreePath()
+	entries := buildWorktreeEntries(wts, nil, primaryPath)
+	if len(entries) == 0 {
+		return "", errors.New("no worktrees available for selection")
+	}
+	collection := newWorktreeCollection(entries, opts)
+	idx, err := fuzzyfinder.Find(&collection.slice, func(i int) string {
+		return collection.itemString(i)
+	},
+		fuzzyfinder.WithPromptString("Select worktree to show logs: "),
+		fuzzyfinder.WithHotReloadLock(&collection.lock),
+	)
+	if err != nil {
+		if errors.Is(err, fuzzyfinder.ErrAbort) {
+			return "", errors.New("selection cancelled")
+		}
+		return "", err
+	}
+	entry, ok := collection.entryByIndex(idx)
+	if !ok {
+		return "", errors.New("selection cancelled")
+	}
+	return entry.rawBranch, nil
+}
+
+// hookLogFiles returns the log files of branch, oldest first, or only the log of hookName.
+func hookLogFiles(branch, hookName string) ([]string, error) {
+	dir, err := state.BranchDir("", branch)
+	if err != nil {
+		return nil, err
+	}
+	if hookName != "" {
+		p := filepath.Join(dir, hookName+".log")
+		if _, err := os.Stat(p); err != nil {
+			return nil, fmt.Errorf("no %s log for branch: %s", hookName, branch)
+		}
+		return []string{p}, nil
+	}
+	matches, _ := filepath.Glob(filepath.Join(dir, "*.log"))
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no hook logs for branch: %s", branch)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return modTime(matches[i]).Before(modTime(matches[j]))
+	})
+	return matches, nil
+}
+
+func modTime(p string) time.Time {
+	fi, err := os.Stat(p)
+	if err != nil {
+		return time.Time{}
+	}
+	return fi.ModTime()
+}
+
+func printLogs(w io.Writer, files []string) error {
+	for i, p := range files {
+		if len(files) > 1 {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			fmt.Fprintf(w, "==> %s <==\n", strings.TrimSuffix(filepath.Base(p), ".log"))
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(w, f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// followLog prints path and then new output as it is appended, reopening the file
+// when it is rotated, until interrupted.
+func followLog(w io.Writer, path string) error {
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt)
+	defer signal.Stop(stop)
+
+	var offset int64
+	for {
+		fi, err := os.Stat(path)
+		if err == nil {
+			if fi.Size() < offset {
+				offset = 0
+			}
+			if fi.Size() > offset {
+				n, err := copyFrom(w, path, offset)
+				if err != nil {
+					return err
+				}
+				offset += n
+			}
+		}
+		select {
+		case <-stop:
+			return nil
+		case <-time.After(logFollowInterval):
+		}
+	}
+}
+
+func copyFrom(w io.Writer, path string, offset int64) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return io.Copy(w, f)
+}
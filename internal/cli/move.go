@@ -10,6 +10,7 @@ import (
 
 	"github.com/sh0o0/gw/internal/fsutil"
 	"github.com/sh0o0/gw/internal/gitx"
+	"github.com/sh0o0/gw/internal/hooks"
 	"github.com/sh0o0/gw/internal/worktree"
 	"github.com/spf13/cobra"
 )
@@ -117,6 +118,10 @@ func moveWorktree(oldBranch, newBranch string) error {
 		destPath = oldPath
 	}
 
+	if err := runHook(hooks.PostMove, hookTarget{Branch: newBranch, Path: destPath, OldBranch: oldBranch, OldPath: oldPath}, loadConfig().HooksBackground); err != nil {
+		return err
+	}
+
 	printPath := destPath
 	if inOld && relWithin != "." {
 		candidate := filepath.Join(destPath, relWithin)
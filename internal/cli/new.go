@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/sh0o0/gw/internal/gitx"
+	"github.com/sh0o0/gw/internal/hooks"
 	"github.com/sh0o0/gw/internal/worktree"
 	"github.com/spf13/cobra"
 )
@@ -17,6 +18,7 @@ func newNewCmd() *cobra.Command {
 	var hookBackground bool
 	var hookForeground bool
 	var verbose bool
+	var conflict worktree.ConflictPolicy
 
 	cmd := &cobra.Command{
 		Use:   "new <branch>",
@@ -59,6 +61,12 @@ func newNewCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
+			target := hookTarget{Branch: branch, Path: p, BaseRef: baseRef, SymlinkSource: symlinkSource}
+			preTarget := target
+			preTarget.Dir = hookDir()
+			if err := runHook(hooks.PreCreate, preTarget, false); err != nil {
+				return err
+			}
 			if _, err := gitx.Cmd("", "worktree", "add", p, "-b", branch, baseRef); err != nil {
 				return err
 			}
@@ -77,11 +85,13 @@ func newNewCmd() *cobra.Command {
 				}
 			}
 
-			if err := createSymlinks(p, PostCreateOptions{Verbose: verbose, SymlinkSource: symlinkSource}); err != nil {
+			if err := createSymlinks(p, PostCreateOptions{Verbose: verbose, SymlinkSource: symlinkSource, Conflict: conflict}); err != nil {
 				return err
 			}
 
-			runPostCreate(branch, p, effectiveHookBg)
+			if err := runPostCreate(target, effectiveHookBg); err != nil {
+				return err
+			}
 
 			return navigateToWorktree(p)
 		},
@@ -95,6 +105,7 @@ func newNewCmd() *cobra.Command {
 	cmd.Flags().BoolVar(&hookBackground, "hook-bg", false, "Run post-create hook in background")
 	cmd.Flags().BoolVar(&hookForeground, "hook-fg", false, "Run post-create hook in foreground (override config)")
 	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Show each symlink created")
+	addConflictFlag(cmd, &conflict)
 	cmd.MarkFlagsMutuallyExclusive("from", "from-current")
 	cmd.MarkFlagsMutuallyExclusive("editor", "no-editor")
 	cmd.MarkFlagsMutuallyExclusive("hook-bg", "hook-fg")
@@ -104,6 +115,7 @@ func newNewCmd() *cobra.Command {
 
 func newAddCmd() *cobra.Command {
 	var verbose bool
+	var conflict worktree.ConflictPolicy
 	var hookBackground bool
 	var hookForeground bool
 	var openEditor bool
@@ -126,6 +138,9 @@ func newAddCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
+			if err := runHook(hooks.PreCreate, hookTarget{Branch: branch, Path: p, Dir: hookDir(), BaseRef: branch}, false); err != nil {
+				return err
+			}
 			if _, err := gitx.Cmd("", "worktree", "add", p, branch); err != nil {
 				return err
 			}
@@ -143,17 +158,20 @@ func newAddCmd() *cobra.Command {
 				}
 			}
 
-			if err := createSymlinks(p, PostCreateOptions{Verbose: verbose}); err != nil {
+			if err := createSymlinks(p, PostCreateOptions{Verbose: verbose, Conflict: conflict}); err != nil {
 				return err
 			}
 
-			runPostCreate(branch, p, effectiveHookBg)
+			if err := runPostCreate(hookTarget{Branch: branch, Path: p, BaseRef: branch}, effectiveHookBg); err != nil {
+				return err
+			}
 
 			return navigateToWorktree(p)
 		},
 	}
 
 	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Show each symlink created")
+	addConflictFlag(cmd, &conflict)
 	cmd.Flags().BoolVar(&hookBackground, "hook-bg", false, "Run post-create hook in background")
 	cmd.Flags().BoolVar(&hookForeground, "hook-fg", false, "Run post-create hook in foreground (override config)")
 	cmd.Flags().BoolVarP(&openEditor, "editor", "e", false, "Open editor after creating worktree")
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"errors"
+
+	"github.com/sh0o0/gw/internal/gitx"
+	"github.com/sh0o0/gw/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+func newPinCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "pin [branch ...]",
+		Short: "Protect worktrees from gw rm, gw gc and the TUI delete",
+		Long: `Pin branches so their worktrees are never removed by gw rm, gw rm --merged,
+gw rm --stale, gw gc or the TUI delete action unless --force-protected is given.
+
+Without arguments the current worktree's branch is pinned. Pins are stored in
+gw.pin; use gw.protect for glob patterns such as release/*.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			branches, err := branchesOrCurrent(args)
+			if err != nil {
+				return err
+			}
+			for _, b := range branches {
+				added, err := worktree.Pin("", b)
+				if err != nil {
+					return err
+				}
+				if added {
+					out.Success("Pinned %s", out.Highlight(b))
+				} else {
+					out.Info("%s is already pinned", out.Highlight(b))
+				}
+			}
+			return nil
+		},
+	}
+}
+
+func newUnpinCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "unpin [branch ...]",
+		Short: "Remove the protection added by gw pin",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			branches, err := branchesOrCurrent(args)
+			if err != nil {
+				return err
+			}
+			for _, b := range branches {
+				removed, err := worktree.Unpin("", b)
+				if err != nil {
+					return err
+				}
+				if removed {
+					out.Success("Unpinned %s", out.Highlight(b))
+				} else {
+					out.Info("%s is not pinned", out.Highlight(b))
+				}
+				if reason := worktree.LoadProtection("").Reason(b); reason != "" {
+					out.Warn("%s is still protected: %s", out.Highlight(b), reason)
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// branchesOrCurrent returns args, or the current worktree's branch when args is empty.
+func branchesOrCurrent(args []string) ([]string, error) {
+	if len(args) > 0 {
+		return args, nil
+	}
+	current, err := gitx.CurrentWorktreePath("")
+	if err != nil {
+		return nil, err
+	}
+	b, err := gitx.BranchAt(current)
+	if err != nil {
+		return nil, err
+	}
+	if b == "" || b == "HEAD" {
+		return nil, errors.New("current worktree is on a detached HEAD; pass a branch")
+	}
+	return []string{b}, nil
+}
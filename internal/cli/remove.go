@@ -5,12 +5,18 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"os/exec"
+	"path/filepath"
 	"sort"
 	"strings"
-	"syscall"
 
+	"github.com/mattn/go-isatty"
+	"github.com/sh0o0/gw/internal/fsutil"
 	"github.com/sh0o0/gw/internal/gitx"
+	"github.com/sh0o0/gw/internal/hooks"
+	"github.com/sh0o0/gw/internal/jobs"
+	"github.com/sh0o0/gw/internal/state"
+	"github.com/sh0o0/gw/internal/trash"
+	"github.com/sh0o0/gw/internal/worktree"
 	"github.com/spf13/cobra"
 
 	fuzzyfinder "github.com/ktr0731/go-fuzzyfinder"
@@ -22,14 +28,38 @@ func newRmCmd() *cobra.Command {
 	var merged bool
 	var background bool
 	var pathArg string
+	var deleteBranch string
+	var keepBranch bool
+	var useTrash, noTrash bool
+	var stale, includeDirty bool
+	var forceProtected bool
+	var olderThan string
 	cmd := &cobra.Command{
 		Use:   "rm [--force] [branch ...]",
 		Short: "Remove worktree(s) by fuzzy select or by branch names",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			removeOpts := removeOptions{force: force, background: background}
+			branchPolicy, err := parseBranchPolicy(deleteBranch, keepBranch)
+			if err != nil {
+				return err
+			}
+			removeOpts := removeOptions{force: force, background: background, branch: branchPolicy, forceProtected: forceProtected}
+			removeOpts.trash = useTrash || (!noTrash && loadConfig().RmTrash)
 			if pathArg != "" {
 				return removeWorktreeForeground(pathArg, removeOpts)
 			}
+			if stale {
+				if merged || len(args) > 0 {
+					return errors.New("--stale cannot be combined with --merged or branch arguments")
+				}
+				maxAge, err := parseAge(olderThan)
+				if err != nil {
+					return err
+				}
+				return removeStaleInteractive(maxAge, includeDirty, removeOpts, opts)
+			}
+			if cmd.Flags().Changed("older-than") || includeDirty {
+				return errors.New("--older-than and --include-dirty require --stale")
+			}
 			if merged {
 				if len(args) > 0 {
 					return errors.New("--merged cannot be combined with branch arguments")
@@ -60,7 +90,16 @@ func newRmCmd() *cobra.Command {
 	cmd.Flags().BoolVar(&force, "force", false, "force remove")
 	cmd.Flags().BoolVar(&opts.showPath, "show-path", false, "display worktree path in fuzzy finder")
 	cmd.Flags().BoolVar(&merged, "merged", false, "remove all merged branches")
+	cmd.Flags().BoolVar(&stale, "stale", false, "remove worktrees without commits, file changes or gw go visits for a while (interactive selection to exclude)")
+	cmd.Flags().StringVar(&olderThan, "older-than", "14d", "with --stale, how long a worktree must be idle (e.g. 14d, 2w)")
+	cmd.Flags().BoolVar(&includeDirty, "include-dirty", false, "with --stale, also offer worktrees with uncommitted changes")
 	cmd.Flags().BoolVar(&background, "bg", false, "Run removal in background")
+	cmd.Flags().StringVar(&deleteBranch, "delete-branch", "safe", "When to delete the branch: safe (keep it if it has unpushed, unmerged commits) or force")
+	cmd.Flags().BoolVar(&keepBranch, "keep-branch", false, "Keep the branch after removing its worktree")
+	cmd.Flags().BoolVar(&forceProtected, "force-protected", false, "Also remove worktrees pinned with gw pin or matching gw.protect")
+	cmd.Flags().BoolVar(&useTrash, "trash", false, "Record the removal so gw restore can bring it back (default: gw.rm.trash)")
+	cmd.Flags().BoolVar(&noTrash, "no-trash", false, "Do not record the removal (override config)")
+	cmd.MarkFlagsMutuallyExclusive("trash", "no-trash")
 	cmd.Flags().StringVar(&pathArg, "path", "", "Remove worktree by path (internal use)")
 	cmd.Flags().MarkHidden("path")
 	return cmd
@@ -69,11 +108,61 @@ func newRmCmd() *cobra.Command {
 type removeOptions struct {
 	force      bool
 	background bool
+	branch     branchPolicy
+	// trash records each removal so it can be undone with gw restore.
+	trash bool
+	// forceProtected allows removing pinned and gw.protect worktrees.
+	forceProtected bool
 }
 
+// branchPolicy decides what happens to a worktree's branch after the worktree is removed.
+type branchPolicy int
+
+const (
+	// branchDeleteSafe deletes the branch unless it has commits that are neither on a
+	// remote nor merged into the base; those are confirmed on a terminal and kept otherwise.
+	branchDeleteSafe branchPolicy = iota
+	branchDeleteForce
+	branchKeep
+)
+
+func parseBranchPolicy(deleteBranch string, keep bool) (branchPolicy, error) {
+	if keep {
+		if deleteBranch == "force" {
+			return 0, errors.New("--keep-branch cannot be combined with --delete-branch=force")
+		}
+		return branchKeep, nil
+	}
+	switch deleteBranch {
+	case "", "safe":
+		return branchDeleteSafe, nil
+	case "force":
+		return branchDeleteForce, nil
+	default:
+		return 0, fmt.Errorf("invalid --delete-branch value %q (want safe or force)", deleteBranch)
+	}
+}
+
+// confirm prints prompt with a [y/N] suffix and reports whether the user answered
+// yes. It refuses without asking when stdin is not a terminal.
+func confirm(prompt string) bool {
+	if !isatty.IsTerminal(os.Stdin.Fd()) {
+		return false
+	}
+	fmt.Fprintf(os.Stderr, "%s [y/N]: ", prompt)
+	return readYes()
+}
+
+// confirmForceRemove asks on stdout and also reads a piped answer, so scripts can
+// still run yes | gw rm.
 func confirmForceRemove(path string) bool {
 	out.Warn("Worktree has uncommitted changes: %s", out.Highlight(path))
 	fmt.Print("Force remove? [y/N]: ")
+	return readYes()
+}
+
+// readYes reads a line from stdin and reports whether it is y or yes.
+func readYes() bool {
 	reader := bufio.NewReader(os.Stdin)
 	input, err := reader.ReadString('\n')
 	if err != nil {
@@ -83,6 +172,18 @@ func confirmForceRemove(path string) bool {
 	return input == "y" || input == "yes"
 }
 
+// checkProtected refuses to remove branch's worktree when it is pinned or matches
+// gw.protect, unless opts.forceProtected is set.
+func checkProtected(branch string, opts removeOptions) error {
+	if opts.forceProtected {
+		return nil
+	}
+	if reason := worktree.LoadProtection("").Reason(branch); reason != "" {
+		return fmt.Errorf("%s is protected (%s); use --force-protected to remove it", branch, reason)
+	}
+	return nil
+}
+
 func removeWorktreeAtPath(path string, opts removeOptions) error {
 	if opts.background {
 		return removeWorktreeInBackground(path, opts)
@@ -91,62 +192,166 @@ func removeWorktreeAtPath(path string, opts removeOptions) error {
 }
 
 func removeWorktreeInBackground(path string, opts removeOptions) error {
+	br, _ := gitx.BranchAt(path)
+	// Refuse here too so the error is not buried in the job log.
+	if err := checkProtected(br, opts); err != nil {
+		return err
+	}
+	if err := checkLocked(path); err != nil {
+		return err
+	}
 	args := []string{"rm", "--path", path}
 	if opts.force {
 		args = append(args, "--force")
 	}
+	switch opts.branch {
+	case branchDeleteForce:
+		args = append(args, "--delete-branch=force")
+	case branchKeep:
+		args = append(args, "--keep-branch")
+	}
+	if opts.trash {
+		args = append(args, "--trash")
+	} else {
+		args = append(args, "--no-trash")
+	}
+	if opts.forceProtected {
+		args = append(args, "--force-protected")
+	}
 
 	exe, err := os.Executable()
 	if err != nil {
 		return fmt.Errorf("failed to get executable path: %w", err)
 	}
 
-	logFile, err := os.CreateTemp("", "gw-rm-*.log")
+	logDir, err := state.BranchDir("", br)
 	if err != nil {
-		return fmt.Errorf("failed to create log file: %w", err)
+		return fmt.Errorf("failed to locate log directory: %w", err)
 	}
-	logPath := logFile.Name()
-
-	cmd := exec.Command(exe, args...)
-	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
-	cmd.Stdout = logFile
-	cmd.Stderr = logFile
-	cmd.Stdin = nil
-	if err := cmd.Start(); err != nil {
-		logFile.Close()
+	job, err := jobs.Start("", jobs.Spec{
+		Args:    append([]string{exe}, args...),
+		Label:   "gw " + strings.Join(args, " "),
+		LogPath: filepath.Join(logDir, "rm.log"),
+	})
+	if err != nil {
 		return fmt.Errorf("failed to start background removal: %w", err)
 	}
-	out.Working("Started background removal for: %s %s", out.Highlight(path), out.Dim(fmt.Sprintf("(PID: %d, log: %s)", cmd.Process.Pid, logPath)))
+	out.Working("Started background removal for: %s %s", out.Highlight(path), out.Dim(fmt.Sprintf("(job %s, PID: %d, log: %s)", job.ID, job.PID, job.LogPath)))
 	return nil
 }
 
 func removeWorktreeForeground(path string, opts removeOptions) error {
 	br, _ := gitx.BranchAt(path)
+	if br == "HEAD" {
+		br = ""
+	}
+	if err := checkProtected(br, opts); err != nil {
+		return err
+	}
+	if err := checkLocked(path); err != nil {
+		return err
+	}
+	if err := runHook(hooks.PreRemove, hookTarget{Branch: br, Path: path}, false); err != nil {
+		return err
+	}
+	var entry *trash.Entry
+	if opts.trash {
+		e, err := trash.Record("", path, br, gitx.BaseRef(""))
+		if err != nil {
+			return fmt.Errorf("failed to record removal in trash: %w", err)
+		}
+		entry = e
+	}
 	out.Trash("Removing worktree: %s", out.Highlight(path))
-	if opts.force {
+	if err := removeWorktreeDir(path, opts.force); err != nil {
+		if entry != nil {
+			_ = entry.Drop("")
+		}
+		return err
+	}
+	if entry != nil {
+		key := entry.ID
+		if br != "" {
+			key = br
+		}
+		out.Info("Saved to trash; undo with %s", out.Highlight("gw restore "+key))
+	}
+	background := loadConfig().HooksBackground
+	if err := runHook(hooks.PostRemove, hookTarget{Branch: br, Path: path, Dir: hookDir()}, background); err != nil {
+		return err
+	}
+	if br != "" {
+		policy := opts.branch
+		if entry != nil && policy == branchDeleteSafe {
+			// The trash entry keeps the branch's commits reachable.
+			policy = branchDeleteForce
+		}
+		deleteWorktreeBranch(br, path, policy)
+	}
+	return nil
+}
+
+// removeWorktreeDir runs git worktree remove, offering to force it when the worktree
+// has uncommitted changes.
+func removeWorktreeDir(path string, force bool) error {
+	if force {
+		_, err := gitx.Cmd("", "worktree", "remove", "--force", path)
+		return err
+	}
+	if _, err := gitx.Cmd("", "worktree", "remove", path); err != nil {
+		if !confirmForceRemove(path) {
+			return err
+		}
 		if _, err := gitx.Cmd("", "worktree", "remove", "--force", path); err != nil {
 			return err
 		}
-	} else {
-		if _, err := gitx.Cmd("", "worktree", "remove", path); err != nil {
-			if confirmForceRemove(path) {
-				if _, err := gitx.Cmd("", "worktree", "remove", "--force", path); err != nil {
-					return err
-				}
-			} else {
-				return err
-			}
+	}
+	return nil
+}
+
+// deleteWorktreeBranch deletes the branch of a removed worktree according to policy.
+// Failing to delete it is reported but does not fail the removal.
+func deleteWorktreeBranch(br, path string, policy branchPolicy) {
+	if policy == branchKeep {
+		out.Info("Keeping branch: %s", out.Highlight(br))
+		return
+	}
+	if policy == branchDeleteSafe {
+		commits, err := gitx.UnsafeCommits("", br)
+		if err != nil {
+			out.Warn("Keeping branch %s: %v", br, err)
+			return
+		}
+		if len(commits) > 0 && !confirmDeleteUnsafeBranch(br, commits) {
+			out.Warn("Keeping branch %s; delete it with %s", out.Highlight(br), out.Highlight("git branch -D "+br))
+			return
 		}
 	}
-	if br != "" && br != "HEAD" {
-		out.Branch("Deleting branch: %s", out.Highlight(br))
-		if _, err := gitx.Cmd("", "branch", "-D", br); err != nil {
-			out.Warn("Failed to delete branch: %s", br)
-		} else {
-			out.Success("Deleted branch: %s", br)
+	if err := runHook(hooks.PreRemoveBranch, hookTarget{Branch: br, Path: path, Dir: hookDir()}, false); err != nil {
+		out.Warn("Keeping branch %s: %v", br, err)
+		return
+	}
+	out.Branch("Deleting branch: %s", out.Highlight(br))
+	if _, err := gitx.Cmd("", "branch", "-D", br); err != nil {
+		out.Warn("Failed to delete branch: %s", br)
+	} else {
+		out.Success("Deleted branch: %s", br)
+	}
+}
+
+// confirmDeleteUnsafeBranch lists the commits that deleting br would lose and asks
+// whether to delete it anyway. It keeps the branch when stdin is not a terminal.
+func confirmDeleteUnsafeBranch(br string, commits []string) bool {
+	out.Warn("Branch %s has %d commit(s) that are not pushed or merged:", out.Highlight(br), len(commits))
+	const shown = 5
+	for i, c := range commits {
+		if i == shown {
+			fmt.Fprintf(os.Stderr, "  %s\n", out.Dim(fmt.Sprintf("... and %d more", len(commits)-shown)))
+			break
 		}
+		fmt.Fprintf(os.Stderr, "  %s\n", c)
 	}
-	return nil
+	return confirm("Delete it anyway?")
 }
 
 func removeInteractive(rmOpts removeOptions, opts fuzzyDisplayOptions) error {
@@ -157,7 +362,7 @@ func removeInteractive(rmOpts removeOptions, opts fuzzyDisplayOptions) error {
 	current, _ := gitx.CurrentWorktreePath("")
 	primaryPath, _ := primaryWorktreePath()
 	entries := buildWorktreeEntries(wts, func(wt gitx.Worktree) bool {
-		return wt.Path == current || samePath(wt.Path, primaryPath)
+		return fsutil.SamePath(wt.Path, current) || fsutil.SamePath(wt.Path, primaryPath)
 	}, primaryPath)
 	if len(entries) == 0 {
 		return errors.New("no worktrees available for selection")
@@ -289,14 +494,20 @@ func removeMergedBranches(opts removeOptions) error {
 
 func removeMergedBranch(branch string, opts removeOptions, primaryPath, currentPath string) error {
 	if p, err := gitx.FindWorktreeByBranch("", branch); err == nil {
-		if samePath(p, primaryPath) {
+		if fsutil.SamePath(p, primaryPath) {
 			return errSkipRemoval
 		}
-		if samePath(p, currentPath) {
+		if fsutil.SamePath(p, currentPath) {
 			return errSkipRemoval
 		}
 		return removeWorktreeAtPath(p, opts)
 	}
+	if err := checkProtected(branch, opts); err != nil {
+		return err
+	}
+	if err := runHook(hooks.PreRemoveBranch, hookTarget{Branch: branch, Dir: hookDir()}, false); err != nil {
+		return err
+	}
 	flag := "-d"
 	if opts.force {
 		flag = "-D"
@@ -317,7 +528,7 @@ func removeMergedInteractive(rmOpts removeOptions, opts fuzzyDisplayOptions) err
 	current, _ := gitx.CurrentWorktreePath("")
 	primaryPath, _ := primaryWorktreePath()
 
-	// Determine merged branches via PR status (gh); filter only MERGED.
+	// Determine merged branches via PR status (gh) or local merge detection; filter only MERGED.
 	root, err := gitx.Root("")
 	if err != nil {
 		root = ""
@@ -327,10 +538,10 @@ func removeMergedInteractive(rmOpts removeOptions, opts fuzzyDisplayOptions) err
 	// Build entries only for merged PR worktrees (exclude current and primary).
 	mergedEntries := make([]*worktreeEntry, 0, len(wts))
 	for _, wt := range wts {
-		if wt.Path == current {
+		if fsutil.SamePath(wt.Path, current) {
 			continue
 		}
-		if samePath(wt.Path, primaryPath) {
+		if fsutil.SamePath(wt.Path, primaryPath) {
 			continue
 		}
 		if wt.Branch == "" || wt.Branch == "HEAD" {
@@ -341,10 +552,12 @@ func removeMergedInteractive(rmOpts removeOptions, opts fuzzyDisplayOptions) err
 			continue
 		}
 		entry := &worktreeEntry{
-			branch:    wt.Branch,
-			rawBranch: wt.Branch,
-			path:      wt.Path,
-			isPrimary: false,
+			branch:     wt.Branch,
+			rawBranch:  wt.Branch,
+			path:       wt.Path,
+			locked:     wt.Locked,
+			lockReason: wt.LockReason,
+			isPrimary:  false,
 		}
 		entry.status.Store(st.Display())
 		mergedEntries = append(mergedEntries, entry)
@@ -353,12 +566,58 @@ func removeMergedInteractive(rmOpts removeOptions, opts fuzzyDisplayOptions) err
 		return errors.New("no merged worktrees to remove")
 	}
 
-	collection := newWorktreeCollection(mergedEntries, opts)
+	return removeAllExceptSelected(mergedEntries, rmOpts, opts, "Select MERGED worktree(s) to EXCLUDE (TAB to exclude, ENTER to remove all):")
+}
+
+// withoutProtected drops the entries whose branch is pinned or matches gw.protect and
+// returns how many were dropped.
+func withoutProtected(entries []*worktreeEntry) ([]*worktreeEntry, int) {
+	prot := worktree.LoadProtection("")
+	kept := entries[:0:0]
+	for _, e := range entries {
+		if prot.Reason(e.rawBranch) == "" {
+			kept = append(kept, e)
+		}
+	}
+	return kept, len(entries) - len(kept)
+}
+
+// withoutLocked drops the entries whose worktree is locked and returns how many were
+// dropped.
+func withoutLocked(entries []*worktreeEntry) ([]*worktreeEntry, int) {
+	kept := entries[:0:0]
+	for _, e := range entries {
+		if !e.locked {
+			kept = append(kept, e)
+		}
+	}
+	return kept, len(entries) - len(kept)
+}
+
+// removeAllExceptSelected shows entries in a multi-select and removes every entry the
+// user did not mark.
+func removeAllExceptSelected(entries []*worktreeEntry, rmOpts removeOptions, opts fuzzyDisplayOptions, prompt string) error {
+	var locked int
+	entries, locked = withoutLocked(entries)
+	if locked > 0 {
+		out.Info("Skipping %d locked worktree(s); use gw unlock to include them", locked)
+	}
+	if !rmOpts.forceProtected {
+		var skipped int
+		entries, skipped = withoutProtected(entries)
+		if skipped > 0 {
+			out.Info("Skipping %d protected worktree(s); use --force-protected to include them", skipped)
+		}
+	}
+	if len(entries) == 0 {
+		return errors.New("no unlocked, unprotected worktrees to remove")
+	}
+	collection := newWorktreeCollection(entries, opts)
 
 	idxs, err := fuzzyfinder.FindMulti(&collection.slice, func(i int) string {
 		return collection.itemString(i)
 	},
-		fuzzyfinder.WithPromptString("Select MERGED worktree(s) to EXCLUDE (TAB to exclude, ENTER to remove all):"),
+		fuzzyfinder.WithPromptString(prompt),
 		fuzzyfinder.WithHotReloadLock(&collection.lock),
 	)
 	if err != nil {
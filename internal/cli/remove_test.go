@@ -7,6 +7,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/sh0o0/gw/internal/trash"
 	"github.com/sh0o0/gw/internal/worktree"
 )
 
@@ -60,3 +61,264 @@ func TestRemoveMergedBranches_shouldRemoveBranches_whenMergedBranchesExist(t *te
 		t.Fatalf("expected worktree directory removed, err=%v", err)
 	}
 }
+
+func TestRemoveWorktreeForeground_shouldAbort_whenPreRemoveHookFails(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	repo := filepath.Join(home, "repo")
+	initTestRepo(t, repo)
+	t.Setenv("GW_CALLER_CWD", repo)
+
+	branch := "feature/keep"
+	wtPath, err := worktree.ComputeWorktreePath(repo, branch)
+	if err != nil {
+		t.Fatalf("compute worktree path: %v", err)
+	}
+	runGit(t, repo, "worktree", "add", wtPath, "-b", branch)
+	runGit(t, repo, "config", "--local", "gw.hooks.pre-remove", "exit 1")
+
+	if err := removeWorktreeForeground(wtPath, removeOptions{}); err == nil {
+		t.Fatal("expected pre-remove hook failure to abort removal")
+	}
+	if _, err := os.Stat(wtPath); err != nil {
+		t.Fatalf("expected worktree to be kept: %v", err)
+	}
+}
+
+func TestRemoveWorktreeForeground_shouldRunPostRemoveHook(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	repo := filepath.Join(home, "repo")
+	initTestRepo(t, repo)
+	t.Setenv("GW_CALLER_CWD", repo)
+
+	branch := "feature/gone"
+	wtPath, err := worktree.ComputeWorktreePath(repo, branch)
+	if err != nil {
+		t.Fatalf("compute worktree path: %v", err)
+	}
+	runGit(t, repo, "worktree", "add", wtPath, "-b", branch)
+	marker := filepath.Join(home, "post-remove.txt")
+	runGit(t, repo, "config", "--local", "gw.hooks.post-remove", "echo $GW_BRANCH > "+marker)
+
+	if err := removeWorktreeForeground(wtPath, removeOptions{}); err != nil {
+		t.Fatalf("removeWorktreeForeground: %v", err)
+	}
+	data, err := os.ReadFile(marker)
+	if err != nil {
+		t.Fatalf("expected post-remove hook to run: %v", err)
+	}
+	if got := strings.TrimSpace(string(data)); got != branch {
+		t.Fatalf("unexpected GW_BRANCH: %q", got)
+	}
+}
+
+func TestRemoveWorktreeForeground_shouldDeleteBranch_whenAbortingPostRemoveHookFails(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	repo := filepath.Join(home, "repo")
+	initTestRepo(t, repo)
+	t.Setenv("GW_CALLER_CWD", repo)
+
+	branch := "feature/gone"
+	wtPath, err := worktree.ComputeWorktreePath(repo, branch)
+	if err != nil {
+		t.Fatalf("compute worktree path: %v", err)
+	}
+	runGit(t, repo, "worktree", "add", wtPath, "-b", branch)
+	runGit(t, repo, "config", "--local", "gw.hooks.post-remove", "exit 1")
+	runGit(t, repo, "config", "--local", "gw.hooks.on-failure", "abort")
+
+	// The worktree is already gone when post-remove runs, so its failure is a warning.
+	if err := removeWorktreeForeground(wtPath, removeOptions{}); err != nil {
+		t.Fatalf("removeWorktreeForeground: %v", err)
+	}
+	if _, err := os.Stat(wtPath); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected worktree removed, err=%v", err)
+	}
+	if branches := runGitOutput(t, repo, "branch", "--list", branch); strings.TrimSpace(branches) != "" {
+		t.Fatalf("expected branch deleted, got %q", branches)
+	}
+}
+
+func TestRemoveWorktreeForeground_shouldKeepBranch_whenCommitsAreUnpushedAndUnmerged(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	repo := filepath.Join(home, "repo")
+	initTestRepo(t, repo)
+	t.Setenv("GW_CALLER_CWD", repo)
+
+	branch := "feature/unpushed"
+	wtPath, err := worktree.ComputeWorktreePath(repo, branch)
+	if err != nil {
+		t.Fatalf("compute worktree path: %v", err)
+	}
+	runGit(t, repo, "worktree", "add", wtPath, "-b", branch)
+	if err := os.WriteFile(filepath.Join(wtPath, "work.txt"), []byte("work"), 0o644); err != nil {
+		t.Fatalf("write work: %v", err)
+	}
+	runGit(t, wtPath, "add", "work.txt")
+	runGit(t, wtPath, "commit", "-m", "feat: local only")
+
+	if err := removeWorktreeForeground(wtPath, removeOptions{}); err != nil {
+		t.Fatalf("removeWorktreeForeground: %v", err)
+	}
+	if _, err := os.Stat(wtPath); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected worktree directory removed, err=%v", err)
+	}
+	if !strings.Contains(runGitOutput(t, repo, "branch", "--list", branch), branch) {
+		t.Fatal("expected branch with unpushed commits to be kept")
+	}
+}
+
+func TestRemoveWorktreeForeground_shouldRefuseProtected_unlessForced(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	repo := filepath.Join(home, "repo")
+	initTestRepo(t, repo)
+	t.Setenv("GW_CALLER_CWD", repo)
+	runGit(t, repo, "config", "--add", "gw.protect", "release/*")
+
+	branch := "release/1.0"
+	wtPath, err := worktree.ComputeWorktreePath(repo, branch)
+	if err != nil {
+		t.Fatalf("compute worktree path: %v", err)
+	}
+	runGit(t, repo, "worktree", "add", wtPath, "-b", branch)
+
+	err = removeWorktreeForeground(wtPath, removeOptions{})
+	if err == nil || !strings.Contains(err.Error(), "protected") {
+		t.Fatalf("expected protected error, got %v", err)
+	}
+	if _, err := os.Stat(wtPath); err != nil {
+		t.Fatalf("expected worktree to be kept: %v", err)
+	}
+	if err := removeWorktreeForeground(wtPath, removeOptions{forceProtected: true}); err != nil {
+		t.Fatalf("removeWorktreeForeground with forceProtected: %v", err)
+	}
+	if _, err := os.Stat(wtPath); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected worktree directory removed, err=%v", err)
+	}
+}
+
+func TestRemoveWorktreeForeground_shouldRefuseLockedWorktree(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	repo := filepath.Join(home, "repo")
+	initTestRepo(t, repo)
+	t.Setenv("GW_CALLER_CWD", repo)
+
+	branch := "feature/locked"
+	wtPath, err := worktree.ComputeWorktreePath(repo, branch)
+	if err != nil {
+		t.Fatalf("compute worktree path: %v", err)
+	}
+	runGit(t, repo, "worktree", "add", wtPath, "-b", branch)
+	runGit(t, repo, "worktree", "lock", "--reason", "on usb drive", wtPath)
+
+	for _, opts := range []removeOptions{{}, {force: true, forceProtected: true}} {
+		err := removeWorktreeForeground(wtPath, opts)
+		if err == nil || !strings.Contains(err.Error(), "locked (on usb drive)") {
+			t.Fatalf("expected locked error with %+v, got %v", opts, err)
+		}
+	}
+	if _, err := os.Stat(wtPath); err != nil {
+		t.Fatalf("expected worktree to be kept: %v", err)
+	}
+}
+
+func TestDeleteWorktreeBranch_shouldFollowPolicy(t *testing.T) {
+	cases := []struct {
+		name     string
+		policy   branchPolicy
+		merge    bool
+		wantKept bool
+	}{
+		{name: "shouldDeleteWhenMergedIntoBase", policy: branchDeleteSafe, merge: true, wantKept: false},
+		{name: "shouldKeepWhenUnmerged", policy: branchDeleteSafe, wantKept: true},
+		{name: "shouldDeleteWhenForced", policy: branchDeleteForce, wantKept: false},
+		{name: "shouldKeepWhenAsked", policy: branchKeep, merge: true, wantKept: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			home := t.TempDir()
+			t.Setenv("HOME", home)
+
+			repo := filepath.Join(home, "repo")
+			initTestRepo(t, repo)
+			t.Setenv("GW_CALLER_CWD", repo)
+
+			branch := "feature/policy"
+			runGit(t, repo, "switch", "-c", branch)
+			if err := os.WriteFile(filepath.Join(repo, "work.txt"), []byte("work"), 0o644); err != nil {
+				t.Fatalf("write work: %v", err)
+			}
+			runGit(t, repo, "add", "work.txt")
+			runGit(t, repo, "commit", "-m", "feat: work")
+			runGit(t, repo, "switch", "main")
+			if tc.merge {
+				runGit(t, repo, "merge", "--ff-only", branch)
+			}
+
+			deleteWorktreeBranch(branch, "", tc.policy)
+
+			kept := strings.Contains(runGitOutput(t, repo, "branch", "--list", branch), branch)
+			if kept != tc.wantKept {
+				t.Fatalf("branch kept = %v, want %v", kept, tc.wantKept)
+			}
+		})
+	}
+}
+
+func TestParseBranchPolicy_shouldRejectInvalidCombinations(t *testing.T) {
+	if _, err := parseBranchPolicy("force", true); err == nil {
+		t.Fatal("expected --keep-branch with --delete-branch=force to fail")
+	}
+	if _, err := parseBranchPolicy("always", false); err == nil {
+		t.Fatal("expected unknown --delete-branch value to fail")
+	}
+	if p, err := parseBranchPolicy("force", false); err != nil || p != branchDeleteForce {
+		t.Fatalf("unexpected policy %v, err=%v", p, err)
+	}
+}
+
+func TestRemoveWorktreeForeground_shouldRecordTrashAndDeleteBranch_whenTrashEnabled(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	repo := filepath.Join(home, "repo")
+	initTestRepo(t, repo)
+	t.Setenv("GW_CALLER_CWD", repo)
+
+	branch := "feature/trashed"
+	wtPath, err := worktree.ComputeWorktreePath(repo, branch)
+	if err != nil {
+		t.Fatalf("compute worktree path: %v", err)
+	}
+	runGit(t, repo, "worktree", "add", wtPath, "-b", branch)
+	if err := os.WriteFile(filepath.Join(wtPath, "work.txt"), []byte("work"), 0o644); err != nil {
+		t.Fatalf("write work: %v", err)
+	}
+	runGit(t, wtPath, "add", "work.txt")
+	runGit(t, wtPath, "commit", "-m", "feat: local only")
+
+	if err := removeWorktreeForeground(wtPath, removeOptions{force: true, trash: true}); err != nil {
+		t.Fatalf("removeWorktreeForeground: %v", err)
+	}
+	if strings.Contains(runGitOutput(t, repo, "branch", "--list", branch), branch) {
+		t.Fatal("expected branch deleted once its commits are kept by the trash")
+	}
+	entries, err := trash.List(repo)
+	if err != nil {
+		t.Fatalf("trash.List: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Branch != branch || entries[0].Path != wtPath {
+		t.Fatalf("unexpected trash entries: %+v", entries)
+	}
+}
@@ -33,6 +33,7 @@ func newRootCmd() *cobra.Command {
 		newMvCmd(),
 		newRmCmd(),
 		newSyncCmd(),
+		newSymlinksCmd(),
 		newSetupCmd(),
 		newShellInitCmd(),
 		newEditorCmd(),
@@ -40,6 +41,15 @@ func newRootCmd() *cobra.Command {
 		newRunCmd(),
 		newConfigCmd(),
 		newTuiCmd(),
+		newLogsCmd(),
+		newJobsCmd(),
+		newTrashCmd(),
+		newRestoreCmd(),
+		newGCCmd(),
+		newPinCmd(),
+		newUnpinCmd(),
+		newLockCmd(),
+		newUnlockCmd(),
 	)
 
 	return cmd
@@ -3,7 +3,9 @@ package cli
 import (
 	"errors"
 
+	"github.com/sh0o0/gw/internal/fsutil"
 	"github.com/sh0o0/gw/internal/gitx"
+	"github.com/sh0o0/gw/internal/worktree"
 	"github.com/spf13/cobra"
 )
 
@@ -12,6 +14,7 @@ func newSetupCmd() *cobra.Command {
 	var hookBackground bool
 	var hookForeground bool
 	var noHooks bool
+	var conflict worktree.ConflictPolicy
 
 	cmd := &cobra.Command{
 		Use:   "setup",
@@ -28,11 +31,11 @@ func newSetupCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
-			if samePath(current, primary) {
+			if fsutil.SamePath(current, primary) {
 				return errors.New("you are in the primary worktree; setup is for secondary worktrees")
 			}
 
-			if err := createSymlinks(current, PostCreateOptions{Verbose: verbose}); err != nil {
+			if err := createSymlinks(current, PostCreateOptions{Verbose: verbose, Conflict: conflict}); err != nil {
 				return err
 			}
 
@@ -41,7 +44,9 @@ func newSetupCmd() *cobra.Command {
 				if err != nil {
 					return err
 				}
-				runPostCreate(branch, current, effectiveHookBg)
+				if err := runPostCreate(hookTarget{Branch: branch, Path: current}, effectiveHookBg); err != nil {
+					return err
+				}
 			}
 
 			out.Success("Setup complete")
@@ -53,6 +58,7 @@ func newSetupCmd() *cobra.Command {
 	cmd.Flags().BoolVar(&hookBackground, "hook-bg", false, "Run post-create hook in background")
 	cmd.Flags().BoolVar(&hookForeground, "hook-fg", false, "Run post-create hook in foreground (override config)")
 	cmd.Flags().BoolVar(&noHooks, "no-hooks", false, "Skip post-create hooks")
+	addConflictFlag(cmd, &conflict)
 	cmd.MarkFlagsMutuallyExclusive("hook-bg", "hook-fg", "no-hooks")
 
 	return cmd
@@ -83,7 +83,7 @@ const fishShellInitScript = `function gw --description 'Git worktree power tool'
     if test $exit_status -eq 0
         if test (count $argv) -gt 0
             switch $argv[1]
-				case go new add mv
+				case go new add mv restore
                     set -l target (string replace -r '\n*$' '' -- $raw)
                     if string match -rq '^/' -- $target
                         if test -d "$target"
@@ -133,7 +133,7 @@ const bashShellInitScript = `gw() {
   local _gw_status=$?
   if [ "$_gw_status" -eq 0 ] && [ $# -gt 0 ]; then
 		case "$1" in
-			go|new|add|mv)
+			go|new|add|mv|restore)
         local _gw_target
         _gw_target="$(printf '%s\n' "$_gw_out" | tail -n 1)"
         if [ -n "$_gw_target" ] && [ "${_gw_target#/}" != "$_gw_target" ] && [ -d "$_gw_target" ]; then
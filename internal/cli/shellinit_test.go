@@ -40,7 +40,7 @@ func TestShellInitContainsNavigationCommands(t *testing.T) {
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if !strings.Contains(fish, "case go new add mv") {
+	if !strings.Contains(fish, "case go new add mv restore") {
 		t.Fatalf("fish script should handle navigation commands")
 	}
 
@@ -48,7 +48,7 @@ func TestShellInitContainsNavigationCommands(t *testing.T) {
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if !strings.Contains(bash, "go|new|add|mv") {
+	if !strings.Contains(bash, "go|new|add|mv|restore") {
 		t.Fatalf("bash script should handle navigation commands")
 	}
 }
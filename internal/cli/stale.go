@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"errors"
+	"time"
+
+	"github.com/sh0o0/gw/internal/fsutil"
+	"github.com/sh0o0/gw/internal/gitx"
+	"github.com/sh0o0/gw/internal/state"
+)
+
+// staleWorktree is a worktree nobody has touched for a while.
+type staleWorktree struct {
+	wt gitx.Worktree
+	// lastActive is the latest of its last commit, newest file and last gw go visit.
+	lastActive time.Time
+	dirty      bool
+}
+
+// findStaleWorktrees returns the worktrees, other than the primary and the current one,
+// whose last activity is older than maxAge. Dirty worktrees are returned only when
+// includeDirty is set; the number skipped is returned as well.
+func findStaleWorktrees(maxAge time.Duration, includeDirty bool, now time.Time) ([]staleWorktree, int, error) {
+	wts, err := gitx.ListWorktrees("")
+	if err != nil {
+		return nil, 0, err
+	}
+	current, _ := gitx.CurrentWorktreePath("")
+	primaryPath, _ := primaryWorktreePath()
+	visits, err := state.Visits("")
+	if err != nil {
+		visits = map[string]time.Time{}
+	}
+
+	var stale []staleWorktree
+	skippedDirty := 0
+	for _, wt := range wts {
+		if fsutil.SamePath(wt.Path, current) || fsutil.SamePath(wt.Path, primaryPath) {
+			continue
+		}
+		last := worktreeLastActive(wt.Path, visits)
+		if now.Sub(last) < maxAge {
+			continue
+		}
+		n, err := gitx.DirtyCount(wt.Path)
+		if err != nil {
+			// Missing or broken worktrees are gw clean's business.
+			continue
+		}
+		if n > 0 && !includeDirty {
+			skippedDirty++
+			continue
+		}
+		stale = append(stale, staleWorktree{wt: wt, lastActive: last, dirty: n > 0})
+	}
+	return stale, skippedDirty, nil
+}
+
+// worktreeLastActive returns the latest of the worktree's last commit, its newest
+// tracked or untracked file and its last gw go visit.
+func worktreeLastActive(path string, visits map[string]time.Time) time.Time {
+	var last time.Time
+	if t, _, err := gitx.LastCommit(path); err == nil && t.After(last) {
+		last = t
+	}
+	if t, err := gitx.LastModified(path); err == nil && t.After(last) {
+		last = t
+	}
+	if t, ok := visits[path]; ok && t.After(last) {
+		last = t
+	}
+	return last
+}
+
+// removeStaleInteractive lists stale worktrees in the same exclude-style picker as
+// removeMergedInteractive and removes the ones not excluded.
+func removeStaleInteractive(maxAge time.Duration, includeDirty bool, rmOpts removeOptions, opts fuzzyDisplayOptions) error {
+	now := time.Now()
+	stale, skippedDirty, err := findStaleWorktrees(maxAge, includeDirty, now)
+	if err != nil {
+		return err
+	}
+	if skippedDirty > 0 {
+		out.Info("Skipping %d dirty worktree(s); use --include-dirty to include them", skippedDirty)
+	}
+	if len(stale) == 0 {
+		return errors.New("no stale worktrees to remove")
+	}
+	entries := make([]*worktreeEntry, 0, len(stale))
+	for _, s := range stale {
+		label := s.wt.Branch
+		if label == "" || label == "HEAD" {
+			label = "(detached)"
+		}
+		entry := &worktreeEntry{
+			branch:     label,
+			rawBranch:  s.wt.Branch,
+			path:       s.wt.Path,
+			locked:     s.wt.Locked,
+			lockReason: s.wt.LockReason,
+		}
+		status := "IDLE " + formatAge(now.Sub(s.lastActive))
+		if s.dirty {
+			status += "*"
+		}
+		entry.status.Store(status)
+		entries = append(entries, entry)
+	}
+	return removeAllExceptSelected(entries, rmOpts, opts, "Select STALE worktree(s) to EXCLUDE (TAB to exclude, ENTER to remove all):")
+}
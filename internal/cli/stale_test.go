@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sh0o0/gw/internal/state"
+	"github.com/sh0o0/gw/internal/worktree"
+)
+
+// ageTree sets the modification time of every file under dir to at.
+func ageTree(t *testing.T, dir string, at time.Time) {
+	t.Helper()
+	err := filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		return os.Chtimes(p, at, at)
+	})
+	if err != nil {
+		t.Fatalf("age %s: %v", dir, err)
+	}
+}
+
+func TestFindStaleWorktrees_shouldPickIdleWorktrees(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_STATE_HOME", "")
+	old := time.Now().Add(-30 * 24 * time.Hour)
+	t.Setenv("GIT_AUTHOR_DATE", old.Format(time.RFC3339))
+	t.Setenv("GIT_COMMITTER_DATE", old.Format(time.RFC3339))
+
+	repo := filepath.Join(home, "repo")
+	initTestRepo(t, repo)
+	t.Setenv("GW_CALLER_CWD", repo)
+
+	add := func(branch string) string {
+		t.Helper()
+		p, err := worktree.ComputeWorktreePath(repo, branch)
+		if err != nil {
+			t.Fatalf("compute worktree path: %v", err)
+		}
+		runGit(t, repo, "worktree", "add", p, "-b", branch)
+		ageTree(t, p, old)
+		return p
+	}
+	idle := add("feature/idle")
+	dirty := add("feature/dirty")
+	visited := add("feature/visited")
+	edited := add("feature/edited")
+	ageTree(t, repo, old)
+
+	if err := os.WriteFile(filepath.Join(dirty, "draft.txt"), []byte("draft"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ageTree(t, dirty, old)
+	if err := state.RecordVisit(repo, visited, time.Now()); err != nil {
+		t.Fatalf("RecordVisit: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(edited, "README.md"), []byte("edited"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, edited, "commit", "-am", "edit")
+
+	stale, skipped, err := findStaleWorktrees(14*24*time.Hour, false, time.Now())
+	if err != nil {
+		t.Fatalf("findStaleWorktrees: %v", err)
+	}
+	if len(stale) != 1 || stale[0].wt.Path != idle || skipped != 1 {
+		t.Fatalf("unexpected stale worktrees: %+v (skipped %d)", stale, skipped)
+	}
+
+	stale, skipped, err = findStaleWorktrees(14*24*time.Hour, true, time.Now())
+	if err != nil {
+		t.Fatalf("findStaleWorktrees: %v", err)
+	}
+	if len(stale) != 2 || skipped != 0 {
+		t.Fatalf("expected idle and dirty worktrees, got %+v (skipped %d)", stale, skipped)
+	}
+	for _, s := range stale {
+		if s.wt.Path == dirty && !s.dirty {
+			t.Fatalf("expected %s marked dirty", dirty)
+		}
+	}
+}
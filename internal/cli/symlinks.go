@@ -0,0 +1,200 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/sh0o0/gw/internal/fsutil"
+	"github.com/sh0o0/gw/internal/gitx"
+	"github.com/sh0o0/gw/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+func newSymlinksCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "symlinks",
+		Short: "Inspect and repair managed symlinks",
+		Long: `Inspect and repair the symlinks and copies gw manages from the primary worktree.
+
+States:
+  ok            symlink resolves to its source, or copy is up to date
+  missing       source exists but the worktree has nothing there
+  dangling      symlink target does not exist
+  wrong-target  symlink resolves somewhere else (e.g. after gw mv)
+  stale         copy whose source changed since it was placed
+  conflict      real file where a symlink should be, or an edited copy
+
+Examples:
+  gw symlinks status
+  gw symlinks status --all-worktrees --json
+  gw symlinks repair --all-worktrees`,
+	}
+	cmd.AddCommand(
+		newSymlinksStatusCmd(),
+		newSymlinksRepairCmd(),
+	)
+	return cmd
+}
+
+func newSymlinksStatusCmd() *cobra.Command {
+	var all, asJSON bool
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show managed symlinks that are ok, missing, dangling or wrong",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			primary, targets, err := symlinkTargets(all)
+			if err != nil {
+				return err
+			}
+			statuses := []worktree.LinkStatus{}
+			for _, t := range targets {
+				st, err := worktree.CheckLinks(primary, t)
+				if err != nil {
+					return err
+				}
+				statuses = append(statuses, st...)
+			}
+			if asJSON {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(statuses)
+			}
+			if len(statuses) == 0 {
+				out.Info("No managed symlinks")
+				return nil
+			}
+			if err := writeLinkStatusTable(cmd.OutOrStdout(), statuses, all); err != nil {
+				return err
+			}
+			if n := brokenLinks(statuses); n > 0 {
+				hint := "gw symlinks repair"
+				if all {
+					hint += " --all-worktrees"
+				}
+				out.Warn("%d path(s) need attention; run %s", n, out.Highlight(hint))
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&all, "all-worktrees", false, "Check every worktree instead of the current one")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Output statuses as JSON")
+	return cmd
+}
+
+func newSymlinksRepairCmd() *cobra.Command {
+	var all bool
+	cmd := &cobra.Command{
+		Use:   "repair",
+		Short: "Re-point or remove broken symlinks and create missing ones",
+		Long: `Re-point dangling and wrong-target symlinks to their source, create missing ones,
+refresh stale copies and remove symlinks whose source no longer exists.
+
+Real files are never touched; resolve conflicts with gw sync --conflict.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			primary, targets, err := symlinkTargets(all)
+			if err != nil {
+				return err
+			}
+			total := 0
+			for _, t := range targets {
+				repairs, err := worktree.RepairLinks(primary, t, worktree.SymlinkOptions{})
+				for _, r := range repairs {
+					out.Link("%s %s", r.Action, out.Highlight(displayLinkPath(r.LinkStatus, all)))
+				}
+				total += len(repairs)
+				if err != nil {
+					return err
+				}
+			}
+			if total == 0 {
+				out.Info("Nothing to repair")
+			} else {
+				out.Success("Repaired %d path(s)", total)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&all, "all-worktrees", false, "Repair every worktree instead of the current one")
+	return cmd
+}
+
+// symlinkTargets returns the primary worktree and the worktrees to check.
+func symlinkTargets(all bool) (string, []string, error) {
+	primary, err := primaryWorktreePath()
+	if err != nil {
+		return "", nil, err
+	}
+	if !all {
+		current, err := gitx.CurrentWorktreePath("")
+		if err != nil {
+			return "", nil, err
+		}
+		if fsutil.SamePath(current, primary) {
+			return "", nil, errors.New("you are in the primary worktree; use --all-worktrees")
+		}
+		return primary, []string{current}, nil
+	}
+	wts, err := gitx.ListWorktrees("")
+	if err != nil {
+		return "", nil, err
+	}
+	var targets []string
+	for _, wt := range wts {
+		if !fsutil.SamePath(wt.Path, primary) && worktreeExists(wt) {
+			targets = append(targets, wt.Path)
+		}
+	}
+	return primary, targets, nil
+}
+
+// worktreeExists reports whether wt's directory is still there. Prunable worktrees
+// are skipped so that creating links never recreates a deleted worktree folder.
+func worktreeExists(wt gitx.Worktree) bool {
+	if wt.Prunable {
+		return false
+	}
+	fi, err := os.Stat(wt.Path)
+	return err == nil && fi.IsDir()
+}
+
+func brokenLinks(statuses []worktree.LinkStatus) int {
+	n := 0
+	for _, st := range statuses {
+		if st.State != worktree.LinkOK {
+			n++
+		}
+	}
+	return n
+}
+
+func displayLinkPath(st worktree.LinkStatus, all bool) string {
+	if all {
+		return st.Worktree + ": " + st.Path
+	}
+	return st.Path
+}
+
+func writeLinkStatusTable(w io.Writer, statuses []worktree.LinkStatus, all bool) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if all {
+		fmt.Fprint(tw, "WORKTREE\t")
+	}
+	fmt.Fprintln(tw, "STATE\tMODE\tPATH\tLINK")
+	for _, st := range statuses {
+		link := st.Link
+		if link == "" {
+			link = "-"
+		}
+		if all {
+			fmt.Fprintf(tw, "%s\t", st.Worktree)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", st.State, st.Mode, st.Path, link)
+	}
+	return tw.Flush()
+}
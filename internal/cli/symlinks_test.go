@@ -0,0 +1,41 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sh0o0/gw/internal/worktree"
+)
+
+func TestSymlinkTargets_shouldSkipDeletedWorktrees_whenAllWorktrees(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	repo := filepath.Join(home, "repo")
+	initTestRepo(t, repo)
+	t.Setenv("GW_CALLER_CWD", repo)
+
+	add := func(branch string) string {
+		t.Helper()
+		p, err := worktree.ComputeWorktreePath(repo, branch)
+		if err != nil {
+			t.Fatalf("compute worktree path: %v", err)
+		}
+		runGit(t, repo, "worktree", "add", p, "-b", branch)
+		return p
+	}
+	live := add("feature/live")
+	gone := add("feature/gone")
+	if err := os.RemoveAll(gone); err != nil {
+		t.Fatal(err)
+	}
+
+	_, targets, err := symlinkTargets(true)
+	if err != nil {
+		t.Fatalf("symlinkTargets: %v", err)
+	}
+	if len(targets) != 1 || targets[0] != live {
+		t.Fatalf("expected only %s, got %v", live, targets)
+	}
+}
@@ -1,20 +1,41 @@
 package cli
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
 
+	"github.com/sh0o0/gw/internal/fsutil"
 	"github.com/sh0o0/gw/internal/gitx"
+	"github.com/sh0o0/gw/internal/hooks"
+	"github.com/sh0o0/gw/internal/state"
 	"github.com/sh0o0/gw/internal/worktree"
 	"github.com/spf13/cobra"
 )
 
 func newSyncCmd() *cobra.Command {
-	var verbose bool
+	var verbose, watch bool
+	var conflict worktree.ConflictPolicy
 
 	cmd := &cobra.Command{
 		Use:   "sync",
-		Short: "Sync symlinks from primary worktree to current worktree",
+		Short: "Sync symlinks and copies from primary worktree to current worktree",
+		Long: `Sync symlinks and copies from the primary worktree to the current worktree.
+
+With --watch, gw keeps running and watches the primary worktree (inotify on Linux,
+polling elsewhere). New files matching gw.symlink.include are linked into every
+secondary worktree as soon as they appear, links to deleted files are removed, and
+each action is printed and appended to a log in gw's state directory.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if watch {
+				return watchSync(cmd.Context())
+			}
 			current, err := gitx.CurrentWorktreePath("")
 			if err != nil {
 				return err
@@ -23,19 +44,86 @@ func newSyncCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
-			if current == primary {
+			if fsutil.SamePath(current, primary) {
 				return errors.New("you are in the primary worktree; nothing to sync")
 			}
-			opts := worktree.SymlinkOptions{Verbose: verbose}
-			count, err := worktree.CreateSymlinksFromGitignored(primary, current, opts)
+			res, err := worktree.CreateSymlinksFromGitignored(primary, current, symlinkOptions(verbose, conflict))
+			reportSymlinkConflicts(res)
 			if err != nil {
 				return err
 			}
-			out.Link("Synced %d symlink(s)", count)
-			return nil
+			out.Link("Synced %d symlink(s)/copies", res.Created)
+			branch, _ := gitx.BranchAt(current)
+			return runHook(hooks.PostSync, hookTarget{Branch: branch, Path: current}, loadConfig().HooksBackground)
 		},
 	}
 
 	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Show each symlink created")
+	cmd.Flags().BoolVarP(&watch, "watch", "w", false, "Keep watching the primary worktree and sync new files into every worktree")
+	addConflictFlag(cmd, &conflict)
+	// Watching only repairs links and never replaces real files.
+	cmd.MarkFlagsMutuallyExclusive("watch", "conflict")
 	return cmd
 }
+
+// watchSync runs worktree.Watch over every secondary worktree until interrupted.
+func watchSync(ctx context.Context) error {
+	primary, err := primaryWorktreePath()
+	if err != nil {
+		return err
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var logw io.Writer = io.Discard
+	logPath := ""
+	if repoDir, err := state.RepoDir(primary); err == nil {
+		logPath = filepath.Join(repoDir, "sync-watch.log")
+		if f, err := state.OpenLog(logPath); err == nil {
+			defer f.Close()
+			logw = f
+		}
+	}
+	logf := func(format string, args ...any) {
+		fmt.Fprintf(logw, "%s %s\n", time.Now().Format(time.RFC3339), fmt.Sprintf(format, args...))
+	}
+
+	out.Working("Watching %s for new files to link (Ctrl-C to stop)", out.Highlight(primary))
+	if logPath != "" {
+		out.Info("Action log: %s", logPath)
+	}
+	logf("watching %s", primary)
+	return worktree.Watch(ctx, primary, worktree.WatchOptions{
+		Targets: func() ([]string, error) {
+			wts, err := gitx.ListWorktrees(primary)
+			if err != nil {
+				return nil, err
+			}
+			var targets []string
+			for _, wt := range wts {
+				if !fsutil.SamePath(wt.Path, primary) && worktreeExists(wt) {
+					targets = append(targets, wt.Path)
+				}
+			}
+			return targets, nil
+		},
+		Report: func(target string, repairs []worktree.Repair, err error) {
+			stamp := time.Now().Format("15:04:05")
+			for _, r := range repairs {
+				out.Link("%s %s %s", out.Dim(stamp), r.Action, out.Highlight(filepath.Join(target, r.Path)))
+				logf("%s %s", r.Action, filepath.Join(target, r.Path))
+			}
+			if err != nil {
+				out.Error("%s sync %s: %v", out.Dim(stamp), target, err)
+				logf("error %s: %v", target, err)
+			}
+		},
+		Polling: func(reason error) {
+			out.Warn("File notifications unavailable (%v); polling instead", reason)
+			logf("polling: %v", reason)
+		},
+	})
+}
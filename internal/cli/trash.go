@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sh0o0/gw/internal/trash"
+	"github.com/spf13/cobra"
+)
+
+func newTrashCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "trash",
+		Short: "List and purge removed worktrees kept for gw restore",
+		Long: `List and purge removed worktrees kept for gw restore.
+
+"gw rm --trash" (or gw.rm.trash=true) records each removal: branch, HEAD, path, base
+ref, and a patch plus an archive of untracked files when the worktree had uncommitted
+changes. The commits stay reachable through refs/gw/trash/* until the entry is
+restored or purged.
+
+Examples:
+  gw trash list
+  gw restore feature/login
+  gw trash purge --older-than 30d`,
+	}
+	cmd.AddCommand(
+		newTrashListCmd(),
+		newTrashPurgeCmd(),
+	)
+	return cmd
+}
+
+func newTrashListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List removed worktrees, newest first",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			entries, err := trash.List("")
+			if err != nil {
+				return err
+			}
+			if len(entries) == 0 {
+				out.Info("Trash is empty")
+				return nil
+			}
+			return writeTrashTable(cmd.OutOrStdout(), entries, time.Now())
+		},
+	}
+}
+
+func newTrashPurgeCmd() *cobra.Command {
+	var olderThan string
+	cmd := &cobra.Command{
+		Use:   "purge --older-than <age>",
+		Short: "Delete trash entries older than an age (e.g. 30d)",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			age, err := parseAge(olderThan)
+			if err != nil {
+				return err
+			}
+			purged, err := trash.Purge("", time.Now().Add(-age))
+			for _, e := range purged {
+				out.Trash("Purged %s", out.Highlight(e.ID))
+			}
+			if err != nil {
+				return err
+			}
+			if len(purged) == 0 {
+				out.Info("Nothing older than %s", olderThan)
+				return nil
+			}
+			out.Success("Purged %d removal(s)", len(purged))
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&olderThan, "older-than", "", "Purge entries removed longer ago than this (e.g. 30d, 2w, 12h)")
+	cmd.MarkFlagRequired("older-than")
+	return cmd
+}
+
+func newRestoreCmd() *cobra.Command {
+	var verbose bool
+	var pathArg string
+	cmd := &cobra.Command{
+		Use:   "restore <branch|id>",
+		Short: "Bring back a worktree removed with gw rm --trash",
+		Long: `Recreate a removed worktree from the trash: the branch (at its recorded HEAD unless it
+still exists), the worktree at its old path, its symlinks, and any uncommitted changes
+saved at removal. Restored changes are left unstaged. The entry is dropped afterwards.
+
+A branch name restores its most recent removal; use an id from gw trash list to pick
+an older one.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			entry, err := trash.Find("", args[0])
+			if err != nil {
+				return err
+			}
+			p, err := trash.Restore("", entry, pathArg)
+			if p == "" {
+				return err
+			}
+			out.Folder("Worktree at %s", out.Highlight(p))
+			if err != nil {
+				return err
+			}
+			if entry.Patch || entry.Untracked > 0 {
+				out.Info("Restored uncommitted changes")
+			}
+			if err := createSymlinks(p, PostCreateOptions{Verbose: verbose}); err != nil {
+				return err
+			}
+			return navigateToWorktree(p)
+		},
+	}
+	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Show each symlink created")
+	cmd.Flags().StringVar(&pathArg, "path", "", "Restore to this path instead of the original one")
+	return cmd
+}
+
+func writeTrashTable(w io.Writer, entries []*trash.Entry, now time.Time) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tBRANCH\tREMOVED\tHEAD\tCHANGES\tPATH")
+	for _, e := range entries {
+		branch := e.Branch
+		if branch == "" {
+			branch = "(detached)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s ago\t%s\t%s\t%s\n",
+			e.ID, branch, formatAge(now.Sub(e.RemovedAt)), shortHash(e.Head), trashChanges(e), e.Path)
+	}
+	return tw.Flush()
+}
+
+func trashChanges(e *trash.Entry) string {
+	var parts []string
+	if e.Patch {
+		parts = append(parts, "patch")
+	}
+	if e.Untracked > 0 {
+		parts = append(parts, fmt.Sprintf("%d untracked", e.Untracked))
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func shortHash(h string) string {
+	if len(h) > 7 {
+		return h[:7]
+	}
+	return h
+}
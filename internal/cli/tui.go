@@ -3,6 +3,8 @@ package cli
 import (
 	"fmt"
 
+	"github.com/sh0o0/gw/internal/gitx"
+	"github.com/sh0o0/gw/internal/hooks"
 	"github.com/sh0o0/gw/internal/tui"
 	"github.com/spf13/cobra"
 )
@@ -19,6 +21,8 @@ func newTuiCmd() *cobra.Command {
 			}
 			if selectedPath != "" {
 				fmt.Println(selectedPath)
+				branch, _ := gitx.BranchAt(selectedPath)
+				return runHook(hooks.PostSwitch, hookTarget{Branch: branch, Path: selectedPath}, loadConfig().HooksBackground)
 			}
 			return nil
 		},
@@ -4,8 +4,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 func ResolveAbs(p string) (string, error) {
@@ -26,16 +28,66 @@ func EnsureDir(p string) error {
 	return os.MkdirAll(p, 0o755)
 }
 
+// RealPath returns p with symlinks resolved, or just cleaned when it cannot be
+// resolved, e.g. because it does not exist.
+func RealPath(p string) string {
+	if r, err := filepath.EvalSymlinks(p); err == nil {
+		return r
+	}
+	return filepath.Clean(p)
+}
+
+// SamePath reports whether a and b name the same location, resolving symlinks such
+// as macOS's /var -> /private/var. Empty paths never match.
+func SamePath(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+	return filepath.Clean(a) == filepath.Clean(b) || RealPath(a) == RealPath(b)
+}
+
+// IsWithin reports whether p is dir or lies below it. Both paths should already be
+// resolved with RealPath.
+func IsWithin(p, dir string) bool {
+	return p == dir || strings.HasPrefix(p, strings.TrimSuffix(dir, string(filepath.Separator))+string(filepath.Separator))
+}
+
+// DirSize returns the total size of the regular files under p. Symlinks are not
+// followed.
+func DirSize(p string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(p, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+	return size, err
+}
+
+// CopyFile copies the contents and permission bits of src to dst.
 func CopyFile(src, dst string) error {
 	in, err := os.Open(src)
 	if err != nil {
 		return err
 	}
 	defer in.Close()
+	fi, err := in.Stat()
+	if err != nil {
+		return err
+	}
 	if err := EnsureDir(filepath.Dir(dst)); err != nil {
 		return err
 	}
-	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, fi.Mode().Perm())
 	if err != nil {
 		return err
 	}
@@ -43,10 +95,16 @@ func CopyFile(src, dst string) error {
 	if _, err := io.Copy(out, in); err != nil {
 		return err
 	}
-	return nil
+	return out.Chmod(fi.Mode().Perm())
 }
 
+// CopyDir copies the tree at src to dst, recreating symlinks instead of following them.
 func CopyDir(src, dst string) error {
+	return CopyDirFunc(src, dst, CopyFile)
+}
+
+// CopyDirFunc is CopyDir with regular files placed by copyFile.
+func CopyDirFunc(src, dst string, copyFile func(src, dst string) error) error {
 	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
@@ -56,10 +114,20 @@ func CopyDir(src, dst string) error {
 			return err
 		}
 		target := filepath.Join(dst, rel)
-		if info.IsDir() {
-			return EnsureDir(target)
+		switch {
+		case info.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0o700)
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case info.Mode().IsRegular():
+			return copyFile(path, target)
+		default:
+			return nil
 		}
-		return CopyFile(path, target)
 	})
 }
 
@@ -76,7 +144,22 @@ func CreateSymlink(src, dst string) error {
 	return os.Symlink(src, dst)
 }
 
-// MaterializeSymlink replaces symlink with actual content.
+// RelativeTarget returns src relative to the real directory that will hold a link at
+// dst, creating that directory if needed.
+func RelativeTarget(src, dst string) (string, error) {
+	dir := filepath.Dir(dst)
+	if err := EnsureDir(dir); err != nil {
+		return "", err
+	}
+	if real, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = real
+	}
+	return filepath.Rel(dir, src)
+}
+
+// MaterializeSymlink replaces symlink with actual content. It returns the absolute
+// path of the content it copied; relative link targets are resolved from the
+// link's real directory.
 func MaterializeSymlink(path string) (string, error) {
 	fi, err := os.Lstat(path)
 	if err != nil {
@@ -89,33 +172,36 @@ func MaterializeSymlink(path string) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	// Determine if link points to dir by checking the link path
-	// We must evaluate relative target from link dir
-	linkDir := filepath.Dir(path)
 	absTarget := target
 	if !filepath.IsAbs(absTarget) {
+		// The kernel resolves a relative target against the directory the link
+		// physically lives in, so ".." must not be applied to a symlinked parent.
+		linkDir := filepath.Dir(path)
+		if real, err := filepath.EvalSymlinks(linkDir); err == nil {
+			linkDir = real
+		}
 		absTarget = filepath.Clean(filepath.Join(linkDir, target))
 	}
 	stat, err := os.Stat(absTarget)
 	if err != nil {
-		return target, err
+		return absTarget, err
 	}
 	// create temp location next to link
 	tmp := path + ".gw_unlink_tmp"
 	if stat.IsDir() {
 		if err := CopyDir(absTarget, tmp); err != nil {
-			return target, err
+			return absTarget, err
 		}
 	} else {
 		if err := CopyFile(absTarget, tmp); err != nil {
-			return target, err
+			return absTarget, err
 		}
 	}
 	if err := os.Remove(path); err != nil {
-		return target, err
+		return absTarget, err
 	}
 	if err := os.Rename(tmp, path); err != nil {
-		return target, err
+		return absTarget, err
 	}
-	return target, nil
+	return absTarget, nil
 }
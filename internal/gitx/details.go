@@ -0,0 +1,131 @@
+package gitx
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DirtyCount returns the number of changed or untracked paths in the worktree at path.
+func DirtyCount(path string) (int, error) {
+	out, err := Cmd(path, "status", "--porcelain")
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, ln := range strings.Split(out, "\n") {
+		if strings.TrimSpace(ln) != "" {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Upstream returns the upstream ref of HEAD in the worktree at path, or "" if none is set.
+func Upstream(path string) string {
+	out, err := Cmd(path, "rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{upstream}")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(out)
+}
+
+// AheadBehind returns how many commits HEAD in the worktree at path is ahead of and behind ref.
+func AheadBehind(path, ref string) (ahead, behind int, err error) {
+	out, err := Cmd(path, "rev-list", "--left-right", "--count", "HEAD..."+ref)
+	if err != nil {
+		return 0, 0, err
+	}
+	fields := strings.Fields(out)
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("unexpected rev-list output: %q", out)
+	}
+	if ahead, err = strconv.Atoi(fields[0]); err != nil {
+		return 0, 0, err
+	}
+	if behind, err = strconv.Atoi(fields[1]); err != nil {
+		return 0, 0, err
+	}
+	return ahead, behind, nil
+}
+
+// LastCommit returns the committer time and subject of HEAD in the worktree at path.
+func LastCommit(path string) (time.Time, string, error) {
+	out, err := Cmd(path, "log", "-1", "--format=%ct%x00%s")
+	if err != nil {
+		return time.Time{}, "", err
+	}
+	parts := strings.SplitN(strings.TrimRight(out, "\n"), "\x00", 2)
+	if len(parts) != 2 {
+		return time.Time{}, "", fmt.Errorf("unexpected log output: %q", out)
+	}
+	sec, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return time.Time{}, "", err
+	}
+	return time.Unix(sec, 0), parts[1], nil
+}
+
+// LastModified returns the newest modification time of the tracked and untracked
+// (not ignored) files in the worktree at path, or the zero time if it has none.
+func LastModified(path string) (time.Time, error) {
+	out, err := Cmd(path, "ls-files", "-z", "--cached", "--others", "--exclude-standard")
+	if err != nil {
+		return time.Time{}, err
+	}
+	var newest time.Time
+	for _, f := range strings.Split(out, "\x00") {
+		if f == "" {
+			continue
+		}
+		fi, err := os.Lstat(filepath.Join(path, f))
+		if err != nil {
+			continue
+		}
+		if mt := fi.ModTime(); mt.After(newest) {
+			newest = mt
+		}
+	}
+	return newest, nil
+}
+
+// StashCounts returns the number of stash entries per branch.
+// Stashes are shared by all worktrees, so they are attributed by the branch they were created on.
+func StashCounts(cwd string) (map[string]int, error) {
+	out, err := Cmd(cwd, "stash", "list", "--format=%gs")
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int)
+	for _, ln := range strings.Split(out, "\n") {
+		if branch := stashBranch(ln); branch != "" {
+			counts[branch]++
+		}
+	}
+	return counts, nil
+}
+
+// stashBranch extracts the branch from a stash subject such as "WIP on main: abc123 msg" or "On main: msg".
+func stashBranch(subject string) string {
+	s := strings.TrimSpace(subject)
+	switch {
+	case strings.HasPrefix(s, "WIP on "):
+		s = strings.TrimPrefix(s, "WIP on ")
+	case strings.HasPrefix(s, "On "):
+		s = strings.TrimPrefix(s, "On ")
+	default:
+		return ""
+	}
+	if i := strings.Index(s, ":"); i > 0 {
+		return s[:i]
+	}
+	return ""
+}
+
+// BaseRef returns the ref used as the comparison base for branch statuses.
+func (r *BranchStatusResolver) BaseRef() string {
+	return r.baseRef
+}
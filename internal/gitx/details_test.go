@@ -0,0 +1,65 @@
+package gitx
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetails_shouldReportCounts_whenWorktreeHasChanges(t *testing.T) {
+	const branchName = "feature/details"
+	repo, branchPath := initStatusTestRepo(t, branchName)
+	if err := os.WriteFile(filepath.Join(branchPath, "work.txt"), []byte("work"), 0o644); err != nil {
+		t.Fatalf("write work: %v", err)
+	}
+	runGitTestHelper(t, branchPath, "add", "work.txt")
+	runGitTestHelper(t, branchPath, "commit", "-m", "feat: add work")
+	if err := os.WriteFile(filepath.Join(branchPath, "a.txt"), []byte("a"), 0o644); err != nil {
+		t.Fatalf("write a: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(branchPath, "b.txt"), []byte("b"), 0o644); err != nil {
+		t.Fatalf("write b: %v", err)
+	}
+
+	dirty, err := DirtyCount(branchPath)
+	if err != nil || dirty != 2 {
+		t.Fatalf("unexpected dirty count: %d err=%v", dirty, err)
+	}
+
+	ahead, behind, err := AheadBehind(branchPath, "main")
+	if err != nil || ahead != 1 || behind != 0 {
+		t.Fatalf("unexpected ahead/behind: %d/%d err=%v", ahead, behind, err)
+	}
+
+	when, subject, err := LastCommit(branchPath)
+	if err != nil || subject != "feat: add work" || when.IsZero() {
+		t.Fatalf("unexpected last commit: %v %q err=%v", when, subject, err)
+	}
+
+	if up := Upstream(branchPath); up != "" {
+		t.Fatalf("expected no upstream, got %q", up)
+	}
+
+	runGitTestHelper(t, branchPath, "stash", "push", "--include-untracked", "-m", "wip")
+	counts, err := StashCounts(repo)
+	if err != nil {
+		t.Fatalf("StashCounts: %v", err)
+	}
+	if counts[branchName] != 1 || counts["main"] != 0 {
+		t.Fatalf("unexpected stash counts: %v", counts)
+	}
+}
+
+func TestStashBranch_shouldParseSubjects(t *testing.T) {
+	cases := map[string]string{
+		"WIP on main: abc123 init":  "main",
+		"On feature/x: custom msg":  "feature/x",
+		"autostash":                 "",
+		"WIP on (no branch): 123 x": "(no branch)",
+	}
+	for subject, want := range cases {
+		if got := stashBranch(subject); got != want {
+			t.Fatalf("stashBranch(%q) = %q, want %q", subject, got, want)
+		}
+	}
+}
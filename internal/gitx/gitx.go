@@ -7,6 +7,8 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 )
 
@@ -67,6 +69,7 @@ func CommonGitDir(cwd string) (string, error) {
 	}
 	p := strings.TrimSpace(out)
 	if !filepath.IsAbs(p) {
+		cwd = effectiveCWD(cwd)
 		if cwd == "" {
 			var err2 error
 			cwd, err2 = os.Getwd()
@@ -79,9 +82,25 @@ func CommonGitDir(cwd string) (string, error) {
 	return p, nil
 }
 
+// GitDir returns the absolute git directory of the worktree containing cwd
+// (<common dir>/worktrees/<name> for linked worktrees).
+func GitDir(cwd string) (string, error) {
+	out, err := Cmd(cwd, "rev-parse", "--absolute-git-dir")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
 type Worktree struct {
 	Path   string
 	Branch string // empty => detached/unknown, "HEAD" for detached head
+	// Locked is set for worktrees locked with git worktree lock; LockReason may be empty.
+	Locked     bool
+	LockReason string
+	// Prunable is set when git worktree prune would remove the entry.
+	Prunable       bool
+	PrunableReason string
 }
 
 // ListWorktrees returns parsed worktrees from `git worktree list --porcelain`.
@@ -90,6 +109,10 @@ func ListWorktrees(cwd string) ([]Worktree, error) {
 	if err != nil {
 		return nil, err
 	}
+	return parseWorktrees(out), nil
+}
+
+func parseWorktrees(out string) []Worktree {
 	lines := strings.Split(out, "\n")
 	var wts []Worktree
 	var cur Worktree
@@ -109,12 +132,87 @@ func ListWorktrees(cwd string) ([]Worktree, error) {
 			cur.Branch = b
 		case strings.HasPrefix(ln, "HEAD "):
 			cur.Branch = "HEAD"
+		case ln == "locked" || strings.HasPrefix(ln, "locked "):
+			cur.Locked = true
+			cur.LockReason = porcelainReason(strings.TrimPrefix(ln, "locked"))
+		case ln == "prunable" || strings.HasPrefix(ln, "prunable "):
+			cur.Prunable = true
+			cur.PrunableReason = porcelainReason(strings.TrimPrefix(ln, "prunable"))
 		}
 	}
 	if cur.Path != "" {
 		wts = append(wts, cur)
 	}
-	return wts, nil
+	return wts
+}
+
+// porcelainReason decodes the reason of a locked or prunable line, which git quotes
+// when it contains special characters.
+func porcelainReason(s string) string {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, `"`) {
+		if u, err := strconv.Unquote(s); err == nil {
+			return u
+		}
+	}
+	return s
+}
+
+// LockWorktree locks the worktree at path so git and gw refuse to remove or prune it.
+func LockWorktree(cwd, path, reason string) error {
+	args := []string{"worktree", "lock"}
+	if reason != "" {
+		args = append(args, "--reason", reason)
+	}
+	_, err := Cmd(cwd, append(args, path)...)
+	return err
+}
+
+// UnlockWorktree unlocks the worktree at path.
+func UnlockWorktree(cwd, path string) error {
+	_, err := Cmd(cwd, "worktree", "unlock", path)
+	return err
+}
+
+// Prunable is a worktree entry that git worktree prune would remove.
+type Prunable struct {
+	// Admin is the administrative directory, e.g. <common dir>/worktrees/feature-x.
+	Admin string
+	// Path is where the worktree was, or empty when git no longer knows.
+	Path   string
+	Reason string
+}
+
+var pruneLine = regexp.MustCompile(`^Removing (\S+): (.+)$`)
+
+// PrunableWorktrees lists the entries git worktree prune would remove.
+func PrunableWorktrees(cwd string) ([]Prunable, error) {
+	commonDir, err := CommonGitDir(cwd)
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command("git", "worktree", "prune", "--dry-run", "--verbose")
+	if dir := effectiveCWD(cwd); dir != "" {
+		cmd.Dir = dir
+	}
+	// git reports what it would prune on stderr.
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("git worktree prune --dry-run failed: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	var res []Prunable
+	for _, ln := range strings.Split(string(out), "\n") {
+		m := pruneLine.FindStringSubmatch(strings.TrimSpace(ln))
+		if m == nil {
+			continue
+		}
+		p := Prunable{Admin: filepath.Join(commonDir, m[1]), Reason: m[2]}
+		if data, err := os.ReadFile(filepath.Join(p.Admin, "gitdir")); err == nil {
+			p.Path = filepath.Dir(strings.TrimSpace(string(data)))
+		}
+		res = append(res, p)
+	}
+	return res, nil
 }
 
 func CurrentWorktreePath(cwd string) (string, error) {
@@ -282,11 +380,22 @@ func ConfigAdd(cwd, key, value string) error {
 	return err
 }
 
+func ConfigAddGlobal(key, value string) error {
+	_, err := Cmd("", "config", "--global", "--add", key, value)
+	return err
+}
+
 func ConfigUnset(cwd, key string) error {
 	_, err := Cmd(cwd, "config", "--local", "--unset-all", key)
 	return err
 }
 
+// ConfigUnsetValue removes every local value of the multi-valued key equal to value.
+func ConfigUnsetValue(cwd, key, value string) error {
+	_, err := Cmd(cwd, "config", "--local", "--fixed-value", "--unset-all", key, value)
+	return err
+}
+
 func PrimaryBranch(cwd string) (string, error) {
 	out, err := Cmd(cwd, "symbolic-ref", "refs/remotes/origin/HEAD")
 	if err == nil {
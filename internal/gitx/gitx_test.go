@@ -0,0 +1,40 @@
+package gitx
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseWorktrees_shouldReadLockedAndPrunable(t *testing.T) {
+	out := `worktree /repo
+HEAD 1111111111111111111111111111111111111111
+branch refs/heads/main
+
+worktree /wt/locked
+HEAD 2222222222222222222222222222222222222222
+branch refs/heads/feature/a
+locked agent running
+
+worktree /wt/quoted
+HEAD 3333333333333333333333333333333333333333
+detached
+locked "line one\nline two"
+
+worktree /wt/gone
+HEAD 4444444444444444444444444444444444444444
+branch refs/heads/feature/gone
+locked
+prunable gitdir file points to non-existent location
+
+`
+	want := []Worktree{
+		{Path: "/repo", Branch: "main"},
+		{Path: "/wt/locked", Branch: "feature/a", Locked: true, LockReason: "agent running"},
+		{Path: "/wt/quoted", Branch: "HEAD", Locked: true, LockReason: "line one\nline two"},
+		{Path: "/wt/gone", Branch: "feature/gone", Locked: true,
+			Prunable: true, PrunableReason: "gitdir file points to non-existent location"},
+	}
+	if got := parseWorktrees(out); !reflect.DeepEqual(got, want) {
+		t.Fatalf("parseWorktrees =\n%+v\nwant\n%+v", got, want)
+	}
+}
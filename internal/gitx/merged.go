@@ -0,0 +1,199 @@
+package gitx
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// MergedInto reports whether the work on branch has landed in base without asking a
+// forge. It recognises, in order:
+//   - plain and fast-forward merges (branch is an ancestor of base),
+//   - rebased or cherry-picked commits (every commit has a patch-equivalent in base),
+//   - squash merges (base's tree equals branch's, or base has a commit with the same
+//     patch-id as the whole branch diffed against the merge base).
+//
+// No objects are written. A branch that is ahead of base costs a git cherry and, for
+// the squash check, a patch-id of the base commits since the merge base that touch
+// the files the branch changed, so callers should not run it more often than they
+// refresh other per-branch status.
+//
+// A branch that never had commits of its own is not considered merged.
+func MergedInto(cwd, branch, base string) (bool, error) {
+	if base == "" || base == branch {
+		return false, nil
+	}
+	tip, err := revParse(cwd, "refs/heads/"+branch+"^{commit}")
+	if err != nil {
+		return false, err
+	}
+	baseTip, err := revParse(cwd, base+"^{commit}")
+	if err != nil {
+		return false, err
+	}
+	out, err := Cmd(cwd, "rev-list", "--count", baseTip+".."+tip)
+	if err != nil {
+		return false, err
+	}
+	ahead, err := strconv.Atoi(strings.TrimSpace(out))
+	if err != nil {
+		return false, err
+	}
+	if ahead == 0 {
+		return hadOwnCommits(cwd, branch, tip), nil
+	}
+
+	if all, err := cherryEquivalent(cwd, baseTip, tip); err != nil || all {
+		return all, err
+	}
+
+	tree, err := revParse(cwd, tip+"^{tree}")
+	if err != nil {
+		return false, err
+	}
+	if baseTree, err := revParse(cwd, baseTip+"^{tree}"); err == nil && baseTree == tree {
+		return true, nil
+	}
+	mergeBase := mergeBaseOf(cwd, baseTip, tip)
+	if mergeBase == "" {
+		return false, nil
+	}
+	if mbTree, err := revParse(cwd, mergeBase+"^{tree}"); err == nil && mbTree == tree {
+		// The branch's changes cancel out; there is nothing to find in base.
+		return false, nil
+	}
+	// Compare the whole branch as one patch against the commits in base since the
+	// merge base, without writing a squash commit. Only commits touching the files
+	// the branch changed can match, so only those are rendered, in full.
+	squash, err := patchIDs(cwd, "diff", mergeBase, tip)
+	if err != nil || len(squash) == 0 {
+		return false, err
+	}
+	logArgs := []string{"log", "-p", "--full-diff", "--full-history", mergeBase + ".." + baseTip}
+	if paths, err := changedPaths(cwd, mergeBase, tip); err == nil && len(paths) > 0 {
+		logArgs = append(append(logArgs, "--"), paths...)
+	}
+	landed, err := patchIDs(cwd, logArgs...)
+	if err != nil {
+		return false, err
+	}
+	for _, id := range landed {
+		if id == squash[0] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// maxPathspecBytes caps the paths passed on one command line; beyond it the squash
+// check scans every base commit instead.
+const maxPathspecBytes = 64 << 10
+
+// changedPaths returns the files that differ between from and to, or nothing when
+// they would not fit on a command line.
+func changedPaths(cwd, from, to string) ([]string, error) {
+	out, err := Cmd(cwd, "diff", "--name-only", "-z", "--no-renames", from, to)
+	if err != nil || len(out) > maxPathspecBytes {
+		return nil, err
+	}
+	var paths []string
+	for _, p := range strings.Split(out, "\x00") {
+		if p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths, nil
+}
+
+// patchIDs pipes the patches printed by git args into git patch-id --stable and
+// returns the patch ids in order.
+func patchIDs(cwd string, args ...string) ([]string, error) {
+	dir := effectiveCWD(cwd)
+	// Both sides must render patches the same way whatever the user's diff config.
+	args = append([]string{"-c", "diff.noprefix=false", "--literal-pathspecs", args[0], "--no-color", "--no-ext-diff", "--no-renames"}, args[1:]...)
+	src := exec.Command("git", args...)
+	src.Dir = dir
+	pid := exec.Command("git", "patch-id", "--stable")
+	pid.Dir = dir
+	pipe, err := src.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	pid.Stdin = pipe
+	var out bytes.Buffer
+	pid.Stdout = &out
+	if err := src.Start(); err != nil {
+		return nil, err
+	}
+	if err := pid.Start(); err != nil {
+		_ = src.Process.Kill()
+		_ = src.Wait()
+		return nil, err
+	}
+	srcErr := src.Wait()
+	if err := pid.Wait(); err != nil {
+		return nil, fmt.Errorf("git patch-id failed: %w", err)
+	}
+	if srcErr != nil {
+		return nil, fmt.Errorf("git %v failed: %w", args, srcErr)
+	}
+	var ids []string
+	for _, line := range strings.Split(out.String(), "\n") {
+		if f := strings.Fields(line); len(f) > 0 {
+			ids = append(ids, f[0])
+		}
+	}
+	return ids, nil
+}
+
+// cherryEquivalent reports whether every commit in upstream..head has a
+// patch-equivalent commit in upstream.
+func cherryEquivalent(cwd, upstream, head string) (bool, error) {
+	out, err := Cmd(cwd, "cherry", upstream, head)
+	if err != nil {
+		return false, err
+	}
+	found := false
+	for _, line := range strings.Split(out, "\n") {
+		switch {
+		case strings.HasPrefix(line, "+"):
+			return false, nil
+		case strings.HasPrefix(line, "-"):
+			found = true
+		}
+	}
+	return found, nil
+}
+
+// hadOwnCommits reports whether branch moved since it was created, according to its
+// reflog. A branch without a reflog is assumed to have none.
+func hadOwnCommits(cwd, branch, tip string) bool {
+	out, err := Cmd(cwd, "reflog", "show", "--format=%H", "refs/heads/"+branch, "--")
+	if err != nil {
+		return false
+	}
+	lines := strings.Fields(out)
+	if len(lines) == 0 {
+		return false
+	}
+	// The oldest entry is where the branch was created.
+	return lines[len(lines)-1] != tip
+}
+
+func mergeBaseOf(cwd, a, b string) string {
+	out, err := Cmd(cwd, "merge-base", a, b)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(out)
+}
+
+func revParse(cwd, rev string) (string, error) {
+	out, err := Cmd(cwd, "rev-parse", "--verify", rev)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
@@ -0,0 +1,145 @@
+package gitx
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func commitFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	runGitTestHelper(t, dir, "add", name)
+	runGitTestHelper(t, dir, "commit", "-m", "change "+name)
+}
+
+func TestMergedInto_shouldDetectMergeStrategies(t *testing.T) {
+	const branch = "feature/merged"
+	cases := []struct {
+		name  string
+		setup func(t *testing.T, repo, wt string)
+		want  bool
+	}{
+		{
+			name:  "shouldReturnFalseWhenBranchHasNoCommits",
+			setup: func(t *testing.T, repo, wt string) {},
+			want:  false,
+		},
+		{
+			name: "shouldReturnFalseWhenBranchHasNoCommitsAndBaseMovedOn",
+			setup: func(t *testing.T, repo, wt string) {
+				commitFile(t, repo, "base.txt", "base")
+			},
+			want: false,
+		},
+		{
+			name: "shouldReturnFalseWhenWorkIsUnmerged",
+			setup: func(t *testing.T, repo, wt string) {
+				commitFile(t, wt, "a.txt", "a")
+			},
+			want: false,
+		},
+		{
+			name: "shouldReturnTrueWhenFastForwarded",
+			setup: func(t *testing.T, repo, wt string) {
+				commitFile(t, wt, "a.txt", "a")
+				runGitTestHelper(t, repo, "merge", "--ff-only", branch)
+			},
+			want: true,
+		},
+		{
+			name: "shouldReturnTrueWhenMergedWithMergeCommit",
+			setup: func(t *testing.T, repo, wt string) {
+				commitFile(t, wt, "a.txt", "a")
+				commitFile(t, repo, "base.txt", "base")
+				runGitTestHelper(t, repo, "merge", "--no-ff", "-m", "merge", branch)
+			},
+			want: true,
+		},
+		{
+			name: "shouldReturnTrueWhenCherryPicked",
+			setup: func(t *testing.T, repo, wt string) {
+				commitFile(t, repo, "base.txt", "base")
+				commitFile(t, wt, "a.txt", "a")
+				commitFile(t, wt, "b.txt", "b")
+				runGitTestHelper(t, repo, "cherry-pick", branch+"~1", branch)
+			},
+			want: true,
+		},
+		{
+			name: "shouldReturnTrueWhenSquashMergedAndBaseMovedOn",
+			setup: func(t *testing.T, repo, wt string) {
+				commitFile(t, wt, "a.txt", "a")
+				commitFile(t, wt, "b.txt", "b")
+				commitFile(t, repo, "base.txt", "base")
+				runGitTestHelper(t, repo, "merge", "--squash", branch)
+				runGitTestHelper(t, repo, "commit", "-m", "squash")
+				commitFile(t, repo, "later.txt", "later")
+			},
+			want: true,
+		},
+		{
+			name: "shouldReturnFalseWhenSquashCommitAlsoChangedOtherFiles",
+			setup: func(t *testing.T, repo, wt string) {
+				commitFile(t, wt, "a.txt", "a")
+				runGitTestHelper(t, repo, "merge", "--squash", branch)
+				commitFile(t, repo, "other.txt", "other")
+			},
+			want: false,
+		},
+		{
+			name: "shouldReturnFalseWhenOnlyPartOfBranchWasSquashed",
+			setup: func(t *testing.T, repo, wt string) {
+				commitFile(t, wt, "a.txt", "a")
+				runGitTestHelper(t, repo, "merge", "--squash", branch)
+				runGitTestHelper(t, repo, "commit", "-m", "squash")
+				commitFile(t, wt, "b.txt", "b")
+			},
+			want: false,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo, wt := initStatusTestRepo(t, branch)
+			tc.setup(t, repo, wt)
+			got, err := MergedInto(repo, branch, "main")
+			if err != nil {
+				t.Fatalf("MergedInto: %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("MergedInto = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMergedInto_shouldNotWriteObjects_whenProbingSquashMerges(t *testing.T) {
+	const branch = "feature/probe"
+	repo, wt := initStatusTestRepo(t, branch)
+	commitFile(t, wt, "a.txt", "a")
+	commitFile(t, wt, "b.txt", "b")
+	commitFile(t, repo, "base.txt", "base")
+	runGitTestHelper(t, repo, "merge", "--squash", branch)
+	runGitTestHelper(t, repo, "commit", "-m", "squash")
+
+	objects := func() string {
+		t.Helper()
+		out, err := Cmd(repo, "count-objects")
+		if err != nil {
+			t.Fatalf("count-objects: %v", err)
+		}
+		return out
+	}
+	before := objects()
+	for i := 0; i < 2; i++ {
+		got, err := MergedInto(repo, branch, "main")
+		if err != nil || !got {
+			t.Fatalf("MergedInto = %v, %v; want true", got, err)
+		}
+	}
+	if after := objects(); after != before {
+		t.Fatalf("expected no new objects, count-objects went from %q to %q", before, after)
+	}
+}
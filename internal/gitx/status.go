@@ -60,6 +60,9 @@ func (r *BranchStatusResolver) StatusInfo(path, branch string) PRInfo {
 	if changed {
 		return PRInfo{Status: BranchStatusInProgress}
 	}
+	if merged, err := MergedInto(path, branch, r.baseRef); err == nil && merged {
+		return PRInfo{Status: BranchStatusMerged}
+	}
 	ahead, err := r.hasLocalCommits(path)
 	if err != nil {
 		return PRInfo{}
@@ -158,6 +161,12 @@ func hasWorkingChanges(path string) (bool, error) {
 	return strings.TrimSpace(out) != "", nil
 }
 
+// BaseRef returns the ref branches are compared against: origin/HEAD, origin/main,
+// origin/master, main or master, whichever exists first.
+func BaseRef(cwd string) string {
+	return detectBaseRef(cwd)
+}
+
 func detectBaseRef(cwd string) string {
 	if out, err := Cmd(cwd, "symbolic-ref", "--quiet", "--short", "refs/remotes/origin/HEAD"); err == nil {
 		ref := strings.TrimSpace(out)
@@ -179,10 +188,33 @@ func detectBaseRef(cwd string) string {
 	return ""
 }
 
-// isMergedIntoBase returns true if all commits on branch are already contained in baseRef.
-// It checks rev-list count of commits reachable from branch but not from baseRef.
-// Note: We intentionally do not infer MERGED purely from Git ancestry here.
-// Use gh PR status to mark MERGED; otherwise fall back to in-progress/not-started.
+// UnsafeCommits returns the commits (as "<short hash> <subject>", newest first) on
+// branch that no remote-tracking branch contains and that are not merged into the
+// base branch. Deleting branch would lose them.
+func UnsafeCommits(cwd, branch string) ([]string, error) {
+	args := []string{"log", "--format=%h %s", "refs/heads/" + branch, "--not", "--remotes"}
+	base := detectBaseRef(cwd)
+	if base != "" && base != branch {
+		args = append(args, base)
+	}
+	out, err := Cmd(cwd, append(args, "--")...)
+	if err != nil {
+		return nil, err
+	}
+	var commits []string
+	for _, line := range strings.Split(out, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			commits = append(commits, line)
+		}
+	}
+	if len(commits) > 0 {
+		// Rebased and squash-merged branches keep their original commits.
+		if merged, err := MergedInto(cwd, branch, base); err == nil && merged {
+			return nil, nil
+		}
+	}
+	return commits, nil
+}
 
 func (s BranchStatus) String() string {
 	return string(s)
@@ -4,6 +4,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -48,7 +49,7 @@ func TestBranchStatusResolver_shouldReportStatus_whenConditionChanges(t *testing
 			want: BranchStatusInProgress,
 		},
 		{
-			name: "shouldReturnNotStartedWhenBranchMergedWithoutPR",
+			name: "shouldReturnMergedWhenBranchMergedWithoutPR",
 			setup: func(t *testing.T) (string, string, string) {
 				repo, branchPath := initStatusTestRepo(t, branchName)
 				path := filepath.Join(branchPath, "merged.txt")
@@ -60,7 +61,7 @@ func TestBranchStatusResolver_shouldReportStatus_whenConditionChanges(t *testing
 				runGitTestHelper(t, repo, "merge", "--ff-only", branchName)
 				return repo, branchPath, branchName
 			},
-			want: BranchStatusNotStarted,
+			want: BranchStatusMerged,
 		},
 	}
 
@@ -218,3 +219,48 @@ func runGitTestHelper(t *testing.T, dir string, args ...string) {
 		t.Fatalf("git %v failed: %v: %s", args, err, out)
 	}
 }
+
+func TestUnsafeCommits_shouldListOnlyCommitsMissingFromRemotesAndBase(t *testing.T) {
+	const branch = "feature/unsafe"
+	repo, branchPath := initStatusTestRepo(t, branch)
+	remote := filepath.Join(filepath.Dir(repo), "remote.git")
+	runGitTestHelper(t, repo, "init", "--bare", remote)
+	runGitTestHelper(t, repo, "remote", "add", "origin", remote)
+
+	commit := func(name string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(branchPath, name), []byte(name), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+		runGitTestHelper(t, branchPath, "add", name)
+		runGitTestHelper(t, branchPath, "commit", "-m", "add "+name)
+	}
+
+	got, err := UnsafeCommits(repo, branch)
+	if err != nil {
+		t.Fatalf("UnsafeCommits: %v", err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("expected no unsafe commits on a fresh branch, got %v", got)
+	}
+
+	commit("pushed.txt")
+	runGitTestHelper(t, branchPath, "push", "origin", branch)
+	commit("local.txt")
+	got, err = UnsafeCommits(repo, branch)
+	if err != nil {
+		t.Fatalf("UnsafeCommits: %v", err)
+	}
+	if len(got) != 1 || !strings.HasSuffix(got[0], "add local.txt") {
+		t.Fatalf("expected only the unpushed commit, got %v", got)
+	}
+
+	runGitTestHelper(t, repo, "merge", "--ff-only", branch)
+	got, err = UnsafeCommits(repo, branch)
+	if err != nil {
+		t.Fatalf("UnsafeCommits: %v", err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("expected no unsafe commits after merging into base, got %v", got)
+	}
+}
@@ -0,0 +1,63 @@
+package hooks
+
+import (
+	"strconv"
+
+	"github.com/sh0o0/gw/internal/fsutil"
+	"github.com/sh0o0/gw/internal/gitx"
+	"github.com/sh0o0/gw/internal/worktree"
+)
+
+// Context describes the worktree a lifecycle event is fired for.
+type Context struct {
+	Event  string
+	Branch string
+	Path   string
+	// BaseRef is the ref passed to `git worktree add` (create events).
+	BaseRef string
+	// SymlinkSource is the worktree symlinks are created from. Defaults to the primary worktree.
+	SymlinkSource string
+	// OldBranch and OldPath describe the worktree before a move.
+	OldBranch string
+	OldPath   string
+}
+
+// Env returns the GW_* variables passed to hook commands. dir is any directory of the
+// repository. Variables whose value is unknown are omitted, except GW_HOOK_NAME,
+// GW_BRANCH and GW_PATH which are always set.
+func (c Context) Env(dir string) map[string]string {
+	env := map[string]string{
+		"GW_HOOK_NAME": c.Event,
+		"GW_BRANCH":    c.Branch,
+		"GW_PATH":      c.Path,
+	}
+	set := func(k, v string) {
+		if v != "" {
+			env[k] = v
+		}
+	}
+	set("GW_BASE_REF", c.BaseRef)
+	set("GW_OLD_BRANCH", c.OldBranch)
+	set("GW_OLD_PATH", c.OldPath)
+
+	if wts, err := gitx.ListWorktrees(dir); err == nil && len(wts) > 0 {
+		set("GW_PRIMARY_PATH", wts[0].Path)
+		if c.SymlinkSource == "" {
+			c.SymlinkSource = wts[0].Path
+		}
+		for i, wt := range wts {
+			if c.Path != "" && fsutil.SamePath(wt.Path, c.Path) {
+				set("GW_WORKTREE_INDEX", strconv.Itoa(i))
+				break
+			}
+		}
+	}
+	set("GW_SYMLINK_SOURCE", c.SymlinkSource)
+
+	if domain, org, repo, has, _ := worktree.ParseRemoteURL(dir); has {
+		set("GW_REPO_DOMAIN", domain)
+		set("GW_REPO_ORG", org)
+		set("GW_REPO_NAME", repo)
+	}
+	return env
+}
@@ -0,0 +1,375 @@
+package hooks
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sh0o0/gw/internal/gitx"
+	"github.com/sh0o0/gw/internal/jobs"
+	"github.com/sh0o0/gw/internal/state"
+)
+
+// ParallelLimit returns how many named hooks of event may run at once:
+// gw.hooks.<event>.parallel, else gw.hooks.parallel, else the number of CPUs.
+func ParallelLimit(cwd, event string) int {
+	for _, key := range []string{configKeyForHook(event) + ".parallel", "gw.hooks.parallel"} {
+		if v, err := gitx.ConfigGet(cwd, key); err == nil {
+			if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil && n > 0 {
+				return n
+			}
+		}
+	}
+	return runtime.NumCPU()
+}
+
+// GroupError reports the named hooks of an event that failed, and those that did not
+// run because a dependency failed or an aborting failure stopped the group.
+type GroupError struct {
+	Hook    string
+	Failed  []*Error
+	Skipped []string
+}
+
+func (e *GroupError) Error() string {
+	parts := make([]string, 0, len(e.Failed))
+	for _, f := range e.Failed {
+		parts = append(parts, f.Error())
+	}
+	msg := fmt.Sprintf("%d %s hook(s) failed: %s", len(e.Failed), e.Hook, strings.Join(parts, "; "))
+	if len(e.Skipped) > 0 {
+		msg += fmt.Sprintf(" (skipped: %s)", strings.Join(e.Skipped, ", "))
+	}
+	return msg
+}
+
+func (e *GroupError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failed))
+	for _, f := range e.Failed {
+		errs = append(errs, f)
+	}
+	return errs
+}
+
+// checkDependencies rejects unknown and cyclic after= references between named hooks.
+func checkDependencies(steps []step) error {
+	byName := map[string]step{}
+	for _, st := range steps {
+		if st.name != "" {
+			byName[st.name] = st
+		}
+	}
+	for _, st := range byName {
+		for _, dep := range st.after {
+			if _, ok := byName[dep]; !ok {
+				return fmt.Errorf("hook %s: unknown dependency %q", st.label, dep)
+			}
+		}
+	}
+	const (
+		visiting = 1
+		done     = 2
+	)
+	marks := map[string]int{}
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch marks[name] {
+		case visiting:
+			return fmt.Errorf("hook dependency cycle: %s", strings.Join(append(path, name), " -> "))
+		case done:
+			return nil
+		}
+		marks[name] = visiting
+		for _, dep := range byName[name].after {
+			if err := visit(dep, append(path, name)); err != nil {
+				return err
+			}
+		}
+		marks[name] = done
+		return nil
+	}
+	for _, st := range steps {
+		if st.name != "" {
+			if err := visit(st.name, nil); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// runner executes the steps of one hook event.
+type runner struct {
+	dir      string
+	event    string
+	branch   string
+	env      []string
+	terminal io.Writer
+	eventLog io.Writer
+}
+
+// runStep runs the commands of st in order, stopping at the first failure.
+// Output goes to output; a header per command goes to log.
+func (r *runner) runStep(st step, output, log io.Writer) *Error {
+	if st.cache != nil {
+		if msg, ok := st.cache.reuse(); ok {
+			fmt.Fprintf(output, "hook %s: %s\n", st.label, msg)
+			return nil
+		}
+	}
+	for _, cmdStr := range st.commands {
+		fmt.Fprintf(log, "\n=== %s: %s ===\n", time.Now().Format(time.RFC3339), cmdStr)
+		if herr := runWithSettings(r.dir, st.hook, cmdStr, r.env, output, st.settings); herr != nil {
+			return herr
+		}
+	}
+	if st.cache != nil {
+		if err := st.cache.record(); err != nil {
+			fmt.Fprintf(output, "hook %s: failed to update cache: %v\n", st.label, err)
+		}
+	}
+	return nil
+}
+
+// runGroup runs named hooks concurrently, at most limit at a time, each once the hooks
+// it depends on have succeeded (or failed with FailIgnore). Hooks depending on a failed
+// hook are skipped; an aborting failure stops hooks that have not started yet.
+func (r *runner) runGroup(steps []step, limit int) *GroupError {
+	const (
+		pending = iota
+		running
+		succeeded
+		failed
+		skipped
+	)
+	type result struct {
+		idx int
+		err *Error
+	}
+	index := map[string]int{}
+	for i, st := range steps {
+		index[st.name] = i
+	}
+	status := make([]int, len(steps))
+	results := make(chan result)
+	var termMu, logMu sync.Mutex
+	gerr := &GroupError{Hook: r.event}
+	stopped := false
+	active := 0
+
+	for {
+		for changed := true; changed; {
+			changed = false
+			for i, st := range steps {
+				if status[i] != pending {
+					continue
+				}
+				ready, blocked := true, stopped
+				for _, dep := range st.after {
+					switch status[index[dep]] {
+					case succeeded:
+					case failed, skipped:
+						blocked = true
+					default:
+						ready = false
+					}
+				}
+				if blocked {
+					status[i] = skipped
+					gerr.Skipped = append(gerr.Skipped, st.name)
+					changed = true
+					continue
+				}
+				if !ready || active >= limit {
+					continue
+				}
+				status[i] = running
+				active++
+				go func(i int, st step) {
+					results <- result{idx: i, err: r.runNamed(st, &termMu, &logMu)}
+				}(i, st)
+			}
+		}
+		if active == 0 {
+			break
+		}
+		res := <-results
+		active--
+		switch {
+		case res.err == nil:
+			status[res.idx] = succeeded
+		case steps[res.idx].settings.OnFailure == FailIgnore:
+			status[res.idx] = succeeded
+		default:
+			status[res.idx] = failed
+			gerr.Failed = append(gerr.Failed, res.err)
+			if res.err.Policy == FailAbort {
+				stopped = true
+			}
+		}
+	}
+	if len(gerr.Failed) == 0 {
+		return nil
+	}
+	return gerr
+}
+
+// runNamed runs one named hook, streaming its output to the terminal prefixed with
+// [<name>] and writing it to the hook's own log.
+func (r *runner) runNamed(st step, termMu, logMu *sync.Mutex) *Error {
+	pw := &prefixWriter{w: r.terminal, mu: termMu, prefix: "[" + st.name + "] "}
+	defer pw.Flush()
+	var output io.Writer = pw
+	var hookLog io.Writer = io.Discard
+	logPath := LogFile(r.dir, r.branch, st.label)
+	if logPath != "" {
+		if f, err := state.OpenLog(logPath); err == nil {
+			defer f.Close()
+			hookLog = f
+			output = io.MultiWriter(pw, f)
+		}
+	}
+	logMu.Lock()
+	fmt.Fprintf(r.eventLog, "\n=== %s: %s (log: %s) ===\n", time.Now().Format(time.RFC3339), st.label, logPath)
+	logMu.Unlock()
+	return r.runStep(st, output, hookLog)
+}
+
+// startBackground starts every step as a detached job. Named hooks write to their own
+// log and wait for the jobs of the hooks they depend on; the parallel limit does not
+// apply. Returns true if anything started or was satisfied from the cache.
+func (r *runner) startBackground(steps []step, logPath string, started func(*jobs.Job)) bool {
+	ran := false
+	// deps maps named hooks started as jobs to their job.
+	deps := map[string]*jobs.Job{}
+	policies := map[string]FailurePolicy{}
+	for _, st := range backgroundOrder(steps) {
+		if st.cache != nil {
+			if msg, ok := st.cache.reuse(); ok {
+				fmt.Fprintf(r.terminal, "hook %s: %s\n", st.label, msg)
+				ran = true
+				continue
+			}
+		}
+		stepLog := logPath
+		var script strings.Builder
+		if st.name != "" {
+			if p := LogFile(r.dir, r.branch, st.label); p != "" && prepareLog(p) == nil {
+				stepLog = p
+			}
+			policies[st.name] = st.settings.OnFailure
+			for _, dep := range st.after {
+				if j, ok := deps[dep]; ok {
+					script.WriteString(waitForDependency(dep, j.ExitFile(), j.PID, policies[dep] == FailIgnore))
+				}
+			}
+		}
+		onSuccess := ""
+		if st.cache != nil {
+			onSuccess = st.cache.recordScript()
+		}
+		script.WriteString(backgroundScript(strings.Join(st.commands, " && "), st.settings, onSuccess))
+		job, err := jobs.Start(r.dir, jobs.Spec{
+			Args:    []string{"sh", "-c", script.String()},
+			Label:   r.event + ": " + st.label,
+			Dir:     r.dir,
+			Env:     r.env,
+			LogPath: stepLog,
+		})
+		if err != nil {
+			if err := startDetached(r.dir, script.String(), r.env, stepLog); err != nil {
+				continue
+			}
+		} else {
+			if st.name != "" {
+				deps[st.name] = job
+			}
+			if started != nil {
+				started(job)
+			}
+		}
+		ran = true
+	}
+	return ran
+}
+
+// backgroundOrder returns steps with every named hook after the hooks it depends on.
+// checkDependencies must have accepted steps.
+func backgroundOrder(steps []step) []step {
+	placed := make([]bool, len(steps))
+	placedNamed := map[string]bool{}
+	res := make([]step, 0, len(steps))
+	for len(res) < len(steps) {
+		for i, st := range steps {
+			if placed[i] {
+				continue
+			}
+			ready := true
+			for _, dep := range st.after {
+				ready = ready && placedNamed[dep]
+			}
+			if ready {
+				placed[i] = true
+				if st.name != "" {
+					placedNamed[st.name] = true
+				}
+				res = append(res, st)
+			}
+		}
+	}
+	return res
+}
+
+// waitForDependency returns a shell prelude that waits for a dependency's job to
+// finish and exits unless it succeeded (or its failures are ignored). The wait ends
+// when the job's process is gone, so a dependency killed before it could record its
+// status does not leave its dependents waiting forever.
+func waitForDependency(name, exitFile string, pid int, ignoreFailure bool) string {
+	f := shellQuote(exitFile)
+	wait := fmt.Sprintf("while [ ! -s %s ]; do kill -0 %d 2>/dev/null || break; sleep 0.2; done; ", f, pid)
+	if ignoreFailure {
+		return wait
+	}
+	return wait + fmt.Sprintf(`[ "$(cat %s 2>/dev/null)" = 0 ] || { echo %s; exit 1; }; `, f, shellQuote("=== Skipped: dependency "+name+" failed ==="))
+}
+
+// prefixWriter prefixes every line written to w. Lines are written whole under mu so
+// concurrent hooks do not interleave within a line.
+type prefixWriter struct {
+	w      io.Writer
+	mu     *sync.Mutex
+	prefix string
+	buf    []byte
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	p.buf = append(p.buf, b...)
+	for {
+		i := bytes.IndexByte(p.buf, '\n')
+		if i < 0 {
+			break
+		}
+		p.emit(p.buf[:i+1])
+		p.buf = p.buf[i+1:]
+	}
+	return len(b), nil
+}
+
+// Flush writes a trailing partial line.
+func (p *prefixWriter) Flush() {
+	if len(p.buf) > 0 {
+		p.emit(append(p.buf, '\n'))
+		p.buf = nil
+	}
+}
+
+func (p *prefixWriter) emit(line []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Fprintf(p.w, "%s%s", p.prefix, line)
+}
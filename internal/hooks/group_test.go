@@ -0,0 +1,145 @@
+package hooks
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunHook_shouldRunNamedHooksConcurrently_respectingAfter(t *testing.T) {
+	tDir := initHookTestRepo(t,
+		[2]string{"gw.hooks.post-create.frontend.command", "sleep 0.5; touch frontend.done"},
+		[2]string{"gw.hooks.post-create.backend.command", "sleep 0.5; touch backend.done"},
+		[2]string{"gw.hooks.post-create.codegen.command", "test -e frontend.done && test -e backend.done && touch codegen.done"},
+		[2]string{"gw.hooks.post-create.codegen.after", "frontend, backend"},
+		[2]string{"gw.hooks.parallel", "2"},
+	)
+
+	start := time.Now()
+	if _, err := RunHook(tDir, PostCreate, nil, Options{Output: io.Discard}); err != nil {
+		t.Fatalf("RunHook: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 900*time.Millisecond {
+		t.Fatalf("expected independent hooks to run concurrently, took %s", elapsed)
+	}
+	if _, err := os.Stat(filepath.Join(tDir, "codegen.done")); err != nil {
+		t.Fatalf("expected codegen to run after its dependencies: %v", err)
+	}
+}
+
+func TestRunHook_shouldPrefixOutputAndWritePerHookLogs(t *testing.T) {
+	tDir := initHookTestRepo(t,
+		[2]string{"gw.hooks.post-create.npm.command", "echo installing; printf partial"},
+	)
+
+	var output bytes.Buffer
+	if _, err := RunHook(tDir, PostCreate, nil, Options{Output: &output, Branch: "feature"}); err != nil {
+		t.Fatalf("RunHook: %v", err)
+	}
+	if output.String() != "[npm] installing\n[npm] partial\n" {
+		t.Fatalf("unexpected output: %q", output.String())
+	}
+	data, err := os.ReadFile(LogFile(tDir, "feature", "post-create.npm"))
+	if err != nil || !strings.Contains(string(data), "installing\npartial") {
+		t.Fatalf("unexpected per-hook log: %q err=%v", data, err)
+	}
+}
+
+func TestRunHook_shouldReportFailedAndSkippedNamedHooks(t *testing.T) {
+	tDir := initHookTestRepo(t,
+		[2]string{"gw.hooks.post-create.deps.command", "exit 2"},
+		[2]string{"gw.hooks.post-create.lint.command", "touch lint.done"},
+		[2]string{"gw.hooks.post-create.build.command", "touch build.done"},
+		[2]string{"gw.hooks.post-create.build.after", "deps"},
+	)
+
+	_, err := RunHook(tDir, PostCreate, nil, Options{Output: io.Discard})
+	var gerr *GroupError
+	if !errors.As(err, &gerr) {
+		t.Fatalf("expected GroupError, got %v", err)
+	}
+	if len(gerr.Failed) != 1 || gerr.Failed[0].Hook != "post-create.deps" || gerr.Failed[0].ExitCode != 2 {
+		t.Fatalf("unexpected failures: %+v", gerr.Failed)
+	}
+	if len(gerr.Skipped) != 1 || gerr.Skipped[0] != "build" {
+		t.Fatalf("unexpected skipped: %v", gerr.Skipped)
+	}
+	if Aborts(err) {
+		t.Fatalf("warn failures must not abort")
+	}
+	if _, err := os.Stat(filepath.Join(tDir, "lint.done")); err != nil {
+		t.Fatalf("expected independent hook to run: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tDir, "build.done")); err == nil {
+		t.Fatalf("expected dependent hook to be skipped")
+	}
+}
+
+func TestRunHook_shouldRejectDependencyCycles(t *testing.T) {
+	tDir := initHookTestRepo(t,
+		[2]string{"gw.hooks.post-create.a.command", "true"},
+		[2]string{"gw.hooks.post-create.a.after", "b"},
+		[2]string{"gw.hooks.post-create.b.command", "true"},
+		[2]string{"gw.hooks.post-create.b.after", "a"},
+	)
+
+	_, err := RunHook(tDir, PostCreate, nil, Options{Output: io.Discard})
+	if err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Fatalf("expected cycle error, got %v", err)
+	}
+}
+
+func TestRunHook_shouldWaitForDependencies_whenBackground(t *testing.T) {
+	tDir := initHookTestRepo(t,
+		[2]string{"gw.hooks.post-create.first.command", "sleep 0.3; touch first.done"},
+		[2]string{"gw.hooks.post-create.second.command", "test -e first.done && echo ok > second.done"},
+		[2]string{"gw.hooks.post-create.second.after", "first"},
+	)
+
+	ran, err := RunHook(tDir, PostCreate, nil, Options{Background: true, Output: io.Discard})
+	if !ran || err != nil {
+		t.Fatalf("expected background hooks to start: ran=%v err=%v", ran, err)
+	}
+	data, err := waitForFile(filepath.Join(tDir, "second.done"), 3*time.Second)
+	if err != nil || strings.TrimSpace(string(data)) != "ok" {
+		t.Fatalf("expected dependent hook to run after its dependency: %q err=%v", data, err)
+	}
+}
+
+func TestWaitForDependency_shouldStop_whenDependencyDiedWithoutExitFile(t *testing.T) {
+	exitFile := filepath.Join(t.TempDir(), "1.exit")
+	dead := exec.Command("true")
+	if err := dead.Run(); err != nil {
+		t.Fatal(err)
+	}
+	for _, ignore := range []bool{false, true} {
+		script := waitForDependency("setup", exitFile, dead.Process.Pid, ignore) + "echo ran"
+		cmd := exec.Command("sh", "-c", script)
+		done := make(chan struct{})
+		var out []byte
+		var err error
+		go func() {
+			out, err = cmd.CombinedOutput()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			_ = cmd.Process.Kill()
+			t.Fatalf("dependent kept waiting for a dead dependency (ignore=%v)", ignore)
+		}
+		if ignore {
+			if err != nil || !strings.Contains(string(out), "ran") {
+				t.Fatalf("expected dependent to run when failures are ignored: %v %s", err, out)
+			}
+		} else if err == nil || !strings.Contains(string(out), "dependency setup failed") {
+			t.Fatalf("expected dependent to be skipped: %v %s", err, out)
+		}
+	}
+}
@@ -1,7 +1,10 @@
 package hooks
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -10,99 +13,317 @@ import (
 	"time"
 
 	"github.com/sh0o0/gw/internal/gitx"
+	"github.com/sh0o0/gw/internal/jobs"
+	"github.com/sh0o0/gw/internal/state"
 )
 
+// Lifecycle events. Commands for each event are read from gw.hooks.<event>.
+const (
+	PreCreate       = "pre-create"
+	PostCreate      = "post-create"
+	PreRemove       = "pre-remove"
+	PostRemove      = "post-remove"
+	PostSwitch      = "post-switch"
+	PostMove        = "post-move"
+	PostSync        = "post-sync"
+	PreRemoveBranch = "pre-remove-branch"
+)
+
+// IsPre reports whether name is a pre-* hook whose failure aborts the operation.
+func IsPre(name string) bool {
+	return strings.HasPrefix(name, "pre-")
+}
+
 type Options struct {
 	Background bool
+	// Branch selects the per-branch log directory. Empty means detached.
+	Branch string
+	// Output receives foreground command output and notes about skipped scripts.
+	// Defaults to os.Stderr.
+	Output io.Writer
+	// Started is called for each background command registered as a job.
+	Started func(*jobs.Job)
+	// Trust is asked before running repository hook scripts that have not been
+	// trusted yet. Nil skips untrusted scripts.
+	Trust TrustFunc
 }
 
 func configKeyForHook(name string) string {
 	return "gw.hooks." + name
 }
 
-func logFilePath(worktreePath, name string) string {
-	return filepath.Join(worktreePath, "gw-hook-"+name+".log")
-}
-
-// LogFile returns the path to the log file for the given hook.
-func LogFile(worktreePath, name string) string {
-	return logFilePath(worktreePath, name)
+// LogFile returns the log file of hook name for branch in the repository containing cwd:
+// $XDG_STATE_HOME/gw/<repo>/<branch>/<name>.log. It returns "" if no state directory is available.
+func LogFile(cwd, branch, name string) string {
+	dir, err := state.BranchDir(cwd, branch)
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, name+".log")
 }
 
-func buildEnvString(env map[string]string) string {
-	var parts []string
-	for k, v := range env {
-		parts = append(parts, fmt.Sprintf("%s=%q", k, v))
-	}
-	return strings.Join(parts, " ")
+// step is a unit of a hook: a plain command, a named hook or a script.
+type step struct {
+	// label identifies the step in logs and errors: the command, or <event>.<name>.
+	label string
+	hook  string
+	// name and after are set for named hooks only.
+	name     string
+	after    []string
+	commands []string
+	settings Settings
+	cache    *inputCache
 }
 
 // RunHook executes hook commands from git config.
-// Commands are read from gw.hook.<name> (multi-value) and executed via sh -c.
-// Global hooks (--global) are executed first, then local hooks.
-// Output is written to <worktreePath>/gw-hook-<name>.log.
-// If opts.Background is true, hooks run in a detached process.
-// Returns true if any hook ran/started and error (only for foreground execution).
+// Commands are read from gw.hooks.<name> (multi-value) and executed via sh -c.
+// Global hooks (--global) are executed first, then local hooks, then named hooks
+// (see Named), then the trusted executable scripts in <worktree root>/.gw/hooks/<name>.d
+// (see Scripts). Named hooks run concurrently, up to ParallelLimit at a time, each
+// after the hooks it lists in after; their output is prefixed with [<name>] and
+// logged to LogFile(<name>.<hook>).
+// Timeouts, retries and the failure policy come from LoadSettings.
+// Output is appended to LogFile (rotated by size). If opts.Background is true, hooks
+// run in a detached process; otherwise output also goes to opts.Output.
+// Returns true if any hook ran/started. Foreground failures are returned as *Error,
+// or *GroupError for named hooks, unless the policy is FailIgnore.
 func RunHook(worktreePath, name string, env map[string]string, opts Options) (ran bool, err error) {
-	key := configKeyForHook(name)
-	globalCmds, _ := gitx.ConfigGetAllGlobal(key)
-	localCmds, _ := gitx.ConfigGetAll(worktreePath, key)
-	cmds := append(globalCmds, localCmds...)
-	if len(cmds) == 0 {
+	output := opts.Output
+	if output == nil {
+		output = os.Stderr
+	}
+	steps := loadSteps(worktreePath, name, opts.Trust, output)
+	if len(steps) == 0 {
 		return false, nil
 	}
+	if err := checkDependencies(steps); err != nil {
+		return false, err
+	}
 
-	logPath := logFilePath(worktreePath, name)
+	logPath := LogFile(worktreePath, opts.Branch, name)
+	envSlice := os.Environ()
+	for k, v := range env {
+		envSlice = append(envSlice, k+"="+v)
+	}
+	r := &runner{dir: worktreePath, event: name, branch: opts.Branch, env: envSlice, terminal: output, eventLog: io.Discard}
 
 	if opts.Background {
-		envStr := buildEnvString(env)
-		for _, cmdStr := range cmds {
-			if cmdStr == "" {
-				continue
+		if logPath == "" {
+			logPath = os.DevNull
+		} else if err := prepareLog(logPath); err != nil {
+			logPath = os.DevNull
+		}
+		return r.startBackground(steps, logPath, opts.Started), nil
+	}
+
+	if logPath != "" {
+		if f, err := state.OpenLog(logPath); err == nil {
+			defer f.Close()
+			r.eventLog = f
+			output = io.MultiWriter(output, f)
+		}
+	}
+	var failure error
+	for i := 0; i < len(steps); {
+		ran = true
+		var ferr error
+		var policy FailurePolicy
+		if steps[i].name != "" {
+			j := i
+			for j < len(steps) && steps[j].name != "" {
+				j++
 			}
-			wrappedCmd := fmt.Sprintf(
-				"{ echo ''; echo '=== '%s': '%s' ==='; %s %s; echo '=== Completed ==='; } >> %q 2>&1",
-				time.Now().Format(time.RFC3339),
-				cmdStr,
-				envStr,
-				cmdStr,
-				logPath,
-			)
-			cmd := exec.Command("sh", "-c", wrappedCmd)
-			cmd.Dir = worktreePath
-			cmd.SysProcAttr = &syscall.SysProcAttr{
-				Setpgid: true,
+			if gerr := r.runGroup(steps[i:j], ParallelLimit(worktreePath, name)); gerr != nil {
+				ferr, policy = gerr, FailWarn
+				if Aborts(gerr) {
+					policy = FailAbort
+				}
 			}
-			cmd.Stdin = nil
-			cmd.Stdout = nil
-			cmd.Stderr = nil
-			if err := cmd.Start(); err != nil {
-				continue
+			i = j
+		} else {
+			st := steps[i]
+			i++
+			if herr := r.runStep(st, output, r.eventLog); herr != nil {
+				ferr, policy = herr, st.settings.OnFailure
 			}
-			ran = true
 		}
-		return ran, nil
+		switch {
+		case ferr == nil:
+		case policy == FailAbort:
+			return true, ferr
+		case policy == FailWarn && failure == nil:
+			failure = ferr
+		}
 	}
+	return ran, failure
+}
 
-	envSlice := os.Environ()
-	for k, v := range env {
-		envSlice = append(envSlice, k+"="+v)
+// loadSteps collects the config commands, named hooks and trusted scripts of hook name.
+func loadSteps(worktreePath, name string, trust TrustFunc, notes io.Writer) []step {
+	key := configKeyForHook(name)
+	globalCmds, _ := gitx.ConfigGetAllGlobal(key)
+	localCmds, _ := gitx.ConfigGetAll(worktreePath, key)
+	settings := LoadSettings(worktreePath, name)
+
+	var steps []step
+	for _, cmdStr := range append(globalCmds, localCmds...) {
+		if cmdStr != "" {
+			steps = append(steps, step{label: cmdStr, hook: name, commands: []string{cmdStr}, settings: settings})
+		}
+	}
+	if named := LoadNamed(worktreePath, name); len(named) > 0 {
+		root, rootErr := gitx.Root(worktreePath)
+		for _, nh := range named {
+			st := step{
+				label:    name + "." + nh.Name,
+				hook:     name + "." + nh.Name,
+				name:     nh.Name,
+				after:    nh.After,
+				commands: nh.Commands,
+				settings: loadNamedSettings(worktreePath, name, nh.Name),
+			}
+			if rootErr == nil {
+				cache, err := newInputCache(root, name, nh)
+				if err != nil {
+					fmt.Fprintf(notes, "hook %s: cannot check inputs: %v\n", st.label, err)
+				}
+				st.cache = cache
+			}
+			steps = append(steps, st)
+		}
 	}
+	for _, script := range trustedScripts(worktreePath, name, trust, notes) {
+		cmdStr := shellQuote(script)
+		steps = append(steps, step{label: cmdStr, hook: name, commands: []string{cmdStr}, settings: settings})
+	}
+	return steps
+}
 
-	for _, cmdStr := range cmds {
-		if cmdStr == "" {
-			continue
+// startDetached runs script without job tracking, used when the job registry is unavailable.
+func startDetached(dir, script string, env []string, logPath string) error {
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+	cmd := exec.Command("sh", "-c", script)
+	cmd.Dir = dir
+	cmd.Env = env
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Stdin = nil
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	return cmd.Start()
+}
+
+// prepareLog creates the log directory and rotates the log before a detached process appends to it.
+func prepareLog(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return state.RotateLog(path, state.MaxLogSize)
+}
+
+// runWithSettings runs cmdStr until it succeeds, times out, or runs out of retries.
+func runWithSettings(dir, name, cmdStr string, env []string, output io.Writer, s Settings) *Error {
+	var lastErr error
+	attempts := 0
+	for attempts <= s.Retries {
+		attempts++
+		timedOut, err := runCommand(dir, cmdStr, env, output, s.Timeout)
+		if err == nil {
+			return nil
 		}
-		cmd := exec.Command("sh", "-c", cmdStr)
-		cmd.Dir = worktreePath
-		cmd.Env = envSlice
-		cmd.Stdout = os.Stderr
-		cmd.Stderr = os.Stderr
-		cmd.Stdin = nil
-		if err := cmd.Run(); err != nil {
-			return true, err
+		herr := &Error{
+			Hook:     name,
+			Command:  cmdStr,
+			Attempts: attempts,
+			ExitCode: exitCode(err),
+			Policy:   s.OnFailure,
+			Err:      err,
 		}
-		ran = true
+		if timedOut {
+			herr.TimedOut = true
+			herr.Timeout = s.Timeout
+			return herr
+		}
+		lastErr = err
+		if attempts <= s.Retries {
+			fmt.Fprintf(output, "hook %s: %q failed (%v), retrying (%d/%d)\n", name, cmdStr, err, attempts, s.Retries)
+		}
+	}
+	return &Error{
+		Hook:     name,
+		Command:  cmdStr,
+		Attempts: attempts,
+		ExitCode: exitCode(lastErr),
+		Policy:   s.OnFailure,
+		Err:      lastErr,
 	}
-	return ran, nil
+}
+
+// runCommand runs cmdStr via sh -c in its own process group and kills the whole
+// group when timeout (if non-zero) expires.
+func runCommand(dir, cmdStr string, env []string, output io.Writer, timeout time.Duration) (timedOut bool, err error) {
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, "sh", "-c", cmdStr)
+	cmd.Dir = dir
+	cmd.Env = env
+	cmd.Stdout = output
+	cmd.Stderr = output
+	cmd.Stdin = nil
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = time.Second
+	err = cmd.Run()
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		return true, err
+	}
+	return false, err
+}
+
+func exitCode(err error) int {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+// backgroundScript wraps a command for detached execution; its output is expected to
+// go to the log. The wrapper retries failed attempts, exits with the command's status
+// and, when a timeout is set, starts a watchdog that records a timeout marker for the
+// job and kills the wrapper's process group. onSuccess, if set, runs once the command
+// has succeeded.
+func backgroundScript(cmdStr string, s Settings, onSuccess string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "echo ''; echo %s; ", shellQuote(fmt.Sprintf("=== %s: %s ===", time.Now().Format(time.RFC3339), cmdStr)))
+	if s.Timeout > 0 {
+		secs := int(s.Timeout.Round(time.Second) / time.Second)
+		if secs < 1 {
+			secs = 1
+		}
+		fmt.Fprintf(&b, "( sleep %d; echo %s; [ -n \"$%s\" ] && { set -C; echo %s > \"$%s\"; } 2>/dev/null; kill -KILL 0 ) & wd=$!; ", secs, shellQuote(fmt.Sprintf("=== Timed out after %s ===", s.Timeout)), jobs.ExitFileEnv, jobs.TimeoutMarker, jobs.ExitFileEnv)
+	}
+	fmt.Fprintf(&b, "attempt=0; while :; do ( %s ); rc=$?; [ $rc -eq 0 ] && break; attempt=$((attempt+1)); [ $attempt -gt %d ] && break; echo \"=== Retrying ($attempt/%d) ===\"; done; ", cmdStr, s.Retries, s.Retries)
+	if s.Timeout > 0 {
+		b.WriteString("kill $wd 2>/dev/null; ")
+	}
+	if onSuccess != "" {
+		fmt.Fprintf(&b, "[ $rc -eq 0 ] && { %s; }; ", onSuccess)
+	}
+	b.WriteString("echo \"=== Completed (exit $rc) ===\"; exit $rc")
+	return b.String()
+}
+
+// shellQuote quotes s for safe use as a single sh word.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
 }
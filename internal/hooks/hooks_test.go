@@ -1,14 +1,30 @@
 package hooks
 
 import (
+	"bytes"
+	"errors"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/sh0o0/gw/internal/jobs"
 )
 
+func TestMain(m *testing.M) {
+	stateDir, err := os.MkdirTemp("", "gw-hooks-state")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("XDG_STATE_HOME", stateDir)
+	code := m.Run()
+	os.RemoveAll(stateDir)
+	os.Exit(code)
+}
+
 func waitForFile(path string, timeout time.Duration) ([]byte, error) {
 	deadline := time.Now().Add(timeout)
 	for time.Now().Before(deadline) {
@@ -188,3 +204,349 @@ func TestRunHook_shouldRunInBackground_whenBackgroundOptionSet(t *testing.T) {
 		t.Fatalf("expected 'background-test', got '%s'", got)
 	}
 }
+
+func TestRunHook_shouldWriteToOutput_whenOutputSet(t *testing.T) {
+	tDir := t.TempDir()
+
+	cmd := exec.Command("git", "init")
+	cmd.Dir = tDir
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("git init: %v", err)
+	}
+
+	cmd = exec.Command("git", "config", "--local", "gw.hooks.pre-remove", "echo captured; exit 3")
+	cmd.Dir = tDir
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("git config: %v", err)
+	}
+
+	var buf bytes.Buffer
+	ran, err := RunHook(tDir, PreRemove, nil, Options{Output: &buf})
+	if !ran || err == nil {
+		t.Fatalf("expected failing hook to run: ran=%v err=%v", ran, err)
+	}
+	if got := strings.TrimSpace(buf.String()); got != "captured" {
+		t.Fatalf("expected captured output, got %q", got)
+	}
+}
+
+func TestIsPre_shouldDetectPreHooks(t *testing.T) {
+	for _, name := range []string{PreCreate, PreRemove, PreRemoveBranch} {
+		if !IsPre(name) {
+			t.Fatalf("expected %s to be a pre hook", name)
+		}
+	}
+	for _, name := range []string{PostCreate, PostRemove, PostSwitch, PostMove, PostSync} {
+		if IsPre(name) {
+			t.Fatalf("expected %s not to be a pre hook", name)
+		}
+	}
+}
+
+func initHookTestRepo(t *testing.T, config ...[2]string) string {
+	t.Helper()
+	tDir := t.TempDir()
+	cmd := exec.Command("git", "init")
+	cmd.Dir = tDir
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("git init: %v", err)
+	}
+	for _, kv := range config {
+		cmd = exec.Command("git", "config", "--local", "--add", kv[0], kv[1])
+		cmd.Dir = tDir
+		if err := cmd.Run(); err != nil {
+			t.Fatalf("git config %s: %v", kv[0], err)
+		}
+	}
+	return tDir
+}
+
+func TestRunHook_shouldKillProcessGroup_whenTimeoutExceeded(t *testing.T) {
+	tDir := initHookTestRepo(t,
+		[2]string{"gw.hooks.post-create", "sleep 10 & sleep 10"},
+		[2]string{"gw.hooks.post-create.timeout", "200ms"},
+	)
+
+	start := time.Now()
+	ran, err := RunHook(tDir, PostCreate, nil, Options{Output: io.Discard})
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("hook was not killed on timeout, took %v", elapsed)
+	}
+	if !ran {
+		t.Fatal("expected hook to run")
+	}
+	var herr *Error
+	if !errors.As(err, &herr) || !herr.TimedOut {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+	if herr.Policy != FailWarn {
+		t.Fatalf("expected default warn policy for post hook, got %s", herr.Policy)
+	}
+}
+
+func TestRunHook_shouldRetry_whenRetriesConfigured(t *testing.T) {
+	counter := filepath.Join(t.TempDir(), "count")
+	tDir := initHookTestRepo(t,
+		[2]string{"gw.hooks.post-create", "echo x >> " + counter + "; [ $(wc -l < " + counter + ") -ge 3 ]"},
+		[2]string{"gw.hooks.retries", "2"},
+	)
+
+	ran, err := RunHook(tDir, PostCreate, nil, Options{Output: io.Discard})
+	if !ran || err != nil {
+		t.Fatalf("expected hook to succeed on third attempt: ran=%v err=%v", ran, err)
+	}
+	data, _ := os.ReadFile(counter)
+	if got := strings.Count(string(data), "x"); got != 3 {
+		t.Fatalf("expected 3 attempts, got %d", got)
+	}
+}
+
+func TestRunHook_shouldApplyFailurePolicy(t *testing.T) {
+	cases := []struct {
+		name       string
+		policy     string
+		wantErr    bool
+		wantSecond bool
+	}{
+		{name: "shouldStopWhenAbort", policy: "abort", wantErr: true, wantSecond: false},
+		{name: "shouldContinueWhenWarn", policy: "warn", wantErr: true, wantSecond: true},
+		{name: "shouldSwallowWhenIgnore", policy: "ignore", wantErr: false, wantSecond: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			marker := filepath.Join(t.TempDir(), "second")
+			tDir := initHookTestRepo(t,
+				[2]string{"gw.hooks.post-create", "exit 4"},
+				[2]string{"gw.hooks.post-create", "touch " + marker},
+				[2]string{"gw.hooks.on-failure", tc.policy},
+			)
+			_, err := RunHook(tDir, PostCreate, nil, Options{Output: io.Discard})
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err != nil {
+				var herr *Error
+				if !errors.As(err, &herr) || herr.ExitCode != 4 {
+					t.Fatalf("expected *Error with exit code 4, got %v", err)
+				}
+				if Aborts(err) != (tc.policy == "abort") {
+					t.Fatalf("unexpected Aborts for policy %s", tc.policy)
+				}
+			}
+			_, statErr := os.Stat(marker)
+			if (statErr == nil) != tc.wantSecond {
+				t.Fatalf("unexpected second command execution: %v", statErr)
+			}
+		})
+	}
+}
+
+func TestRunHook_shouldLogTimeout_whenBackgroundTimeoutExceeded(t *testing.T) {
+	tDir := initHookTestRepo(t,
+		[2]string{"gw.hooks.post-create", "sleep 10"},
+		[2]string{"gw.hooks.timeout", "1"},
+	)
+
+	ran, err := RunHook(tDir, PostCreate, nil, Options{Background: true})
+	if !ran || err != nil {
+		t.Fatalf("expected background hook to start: ran=%v err=%v", ran, err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		data, _ := os.ReadFile(LogFile(tDir, "", PostCreate))
+		if strings.Contains(string(data), "Timed out") {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatal("expected timeout to be logged")
+}
+
+func TestParseTimeout_shouldAcceptSecondsAndDurations(t *testing.T) {
+	if d, err := ParseTimeout("30"); err != nil || d != 30*time.Second {
+		t.Fatalf("unexpected: %v %v", d, err)
+	}
+	if d, err := ParseTimeout("5m"); err != nil || d != 5*time.Minute {
+		t.Fatalf("unexpected: %v %v", d, err)
+	}
+	if _, err := ParseTimeout("soon"); err == nil {
+		t.Fatal("expected error for invalid timeout")
+	}
+}
+
+func TestRunHook_shouldWriteLogToStateDir_whenForeground(t *testing.T) {
+	tDir := initHookTestRepo(t, [2]string{"gw.hooks.post-create", "echo logged-output"})
+
+	ran, err := RunHook(tDir, PostCreate, nil, Options{Branch: "feature/log", Output: io.Discard})
+	if !ran || err != nil {
+		t.Fatalf("hook did not run successfully: ran=%v err=%v", ran, err)
+	}
+
+	logPath := LogFile(tDir, "feature/log", PostCreate)
+	if !strings.HasPrefix(logPath, os.Getenv("XDG_STATE_HOME")) || filepath.Base(filepath.Dir(logPath)) != "feature-log" {
+		t.Fatalf("unexpected log path: %s", logPath)
+	}
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("read log: %v", err)
+	}
+	if !strings.Contains(string(data), "logged-output") {
+		t.Fatalf("expected output in log, got %q", string(data))
+	}
+	if _, err := os.Stat(filepath.Join(tDir, "gw-hook-post-create.log")); err == nil {
+		t.Fatal("expected no log file inside the worktree")
+	}
+}
+
+func TestRunHook_shouldRegisterJob_whenBackground(t *testing.T) {
+	tDir := initHookTestRepo(t,
+		[2]string{"gw.hooks.post-create", "echo started; exit 5"},
+		[2]string{"gw.hooks.post-create", "sleep 10"},
+		[2]string{"gw.hooks.timeout", "1"},
+	)
+
+	var started []*jobs.Job
+	ran, err := RunHook(tDir, PostCreate, nil, Options{
+		Background: true,
+		Started:    func(j *jobs.Job) { started = append(started, j) },
+	})
+	if !ran || err != nil {
+		t.Fatalf("expected background hook to start: ran=%v err=%v", ran, err)
+	}
+	if len(started) != 2 {
+		t.Fatalf("expected 2 jobs, got %d", len(started))
+	}
+	failed := started[0].Wait(20 * time.Millisecond)
+	if failed.Status != jobs.StatusExited || failed.ExitCode != 5 {
+		t.Fatalf("expected exit 5, got %s %d", failed.Status, failed.ExitCode)
+	}
+	timedOut := started[1].Wait(50 * time.Millisecond)
+	if timedOut.Status != jobs.StatusTimedOut {
+		t.Fatalf("expected timed out, got %s %d", timedOut.Status, timedOut.ExitCode)
+	}
+	if timedOut.LogPath != LogFile(tDir, "", PostCreate) {
+		t.Fatalf("unexpected job log: %s", timedOut.LogPath)
+	}
+}
+
+func writeHookScript(t *testing.T, root, name, file, body string, mode os.FileMode) {
+	t.Helper()
+	dir := filepath.Join(root, ScriptsDir, name+".d")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, file), []byte(body), mode); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunHook_shouldRunTrustedScriptsAfterConfigHooks(t *testing.T) {
+	tDir := initHookTestRepo(t, [2]string{"gw.hooks.post-create", "echo config >> order.txt"})
+	writeHookScript(t, tDir, PostCreate, "20-second", "#!/bin/sh\necho \"second $GW_BRANCH\" >> order.txt\n", 0o755)
+	writeHookScript(t, tDir, PostCreate, "10-first", "#!/bin/sh\necho first >> order.txt\n", 0o755)
+	writeHookScript(t, tDir, PostCreate, "README", "not a script\n", 0o644)
+
+	asked := 0
+	trust := func(scripts []string) bool {
+		asked++
+		if len(scripts) != 2 || scripts[0] != filepath.Join(ScriptsDir, "post-create.d", "10-first") {
+			t.Fatalf("unexpected scripts: %v", scripts)
+		}
+		return true
+	}
+	env := map[string]string{"GW_BRANCH": "feature"}
+	for i := 0; i < 2; i++ {
+		if _, err := RunHook(tDir, PostCreate, env, Options{Output: io.Discard, Trust: trust}); err != nil {
+			t.Fatalf("RunHook: %v", err)
+		}
+	}
+	if asked != 1 {
+		t.Fatalf("expected to be asked once, got %d", asked)
+	}
+	data, _ := os.ReadFile(filepath.Join(tDir, "order.txt"))
+	want := "config\nfirst\nsecond feature\nconfig\nfirst\nsecond feature\n"
+	if string(data) != want {
+		t.Fatalf("unexpected order:\n%s", data)
+	}
+
+	writeHookScript(t, tDir, PostCreate, "10-first", "#!/bin/sh\necho changed >> order.txt\n", 0o755)
+	if _, err := RunHook(tDir, PostCreate, env, Options{Output: io.Discard, Trust: trust}); err != nil {
+		t.Fatalf("RunHook: %v", err)
+	}
+	if asked != 2 {
+		t.Fatalf("expected to be asked again after scripts changed, got %d", asked)
+	}
+}
+
+func TestRunHook_shouldSkipScripts_whenNotTrusted(t *testing.T) {
+	tDir := initHookTestRepo(t)
+	writeHookScript(t, tDir, PostCreate, "setup", "#!/bin/sh\ntouch ran.txt\n", 0o755)
+
+	var output bytes.Buffer
+	ran, err := RunHook(tDir, PostCreate, nil, Options{Output: &output, Trust: func([]string) bool { return false }})
+	if ran || err != nil {
+		t.Fatalf("expected nothing to run: ran=%v err=%v", ran, err)
+	}
+	if _, err := os.Stat(filepath.Join(tDir, "ran.txt")); err == nil {
+		t.Fatal("untrusted script was executed")
+	}
+	if !strings.Contains(output.String(), "untrusted") {
+		t.Fatalf("expected skip note, got %q", output.String())
+	}
+}
+
+func TestRunHook_shouldPassEnvVerbatim_whenBackground(t *testing.T) {
+	tDir := initHookTestRepo(t, [2]string{"gw.hooks.post-create", `printf '%s' "$GW_BRANCH" > env.txt`})
+
+	branch := "feat/$HOME-`id`-\"q\"-'s'"
+	ran, err := RunHook(tDir, PostCreate, map[string]string{"GW_BRANCH": branch}, Options{Background: true, Output: io.Discard})
+	if !ran || err != nil {
+		t.Fatalf("expected background hook to start: ran=%v err=%v", ran, err)
+	}
+	data, err := waitForFile(filepath.Join(tDir, "env.txt"), 2*time.Second)
+	if err != nil {
+		t.Fatalf("failed to read env file: %v", err)
+	}
+	if string(data) != branch {
+		t.Fatalf("expected %q, got %q", branch, data)
+	}
+}
+
+func TestContextEnv_shouldDescribeRepositoryAndWorktree(t *testing.T) {
+	tDir, _ := filepath.EvalSymlinks(initHookTestRepo(t))
+	for _, args := range [][]string{
+		{"remote", "add", "origin", "git@github.com:example/repo.git"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--allow-empty", "-m", "init"},
+		{"worktree", "add", "-b", "feature", filepath.Join(tDir, "wt")},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = tDir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	wt := filepath.Join(tDir, "wt")
+
+	env := Context{Event: PostMove, Branch: "feature", Path: wt, OldBranch: "old", OldPath: "/tmp/old"}.Env(tDir)
+	want := map[string]string{
+		"GW_HOOK_NAME":      PostMove,
+		"GW_BRANCH":         "feature",
+		"GW_PATH":           wt,
+		"GW_OLD_BRANCH":     "old",
+		"GW_OLD_PATH":       "/tmp/old",
+		"GW_PRIMARY_PATH":   tDir,
+		"GW_SYMLINK_SOURCE": tDir,
+		"GW_WORKTREE_INDEX": "1",
+		"GW_REPO_DOMAIN":    "github.com",
+		"GW_REPO_ORG":       "example",
+		"GW_REPO_NAME":      "repo",
+	}
+	for k, v := range want {
+		if env[k] != v {
+			t.Errorf("%s: expected %q, got %q", k, v, env[k])
+		}
+	}
+	if _, ok := env["GW_BASE_REF"]; ok {
+		t.Errorf("GW_BASE_REF should be omitted when unknown")
+	}
+}
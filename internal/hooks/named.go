@@ -0,0 +1,249 @@
+package hooks
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/sh0o0/gw/internal/fsutil"
+	"github.com/sh0o0/gw/internal/gitx"
+	"github.com/sh0o0/gw/internal/state"
+)
+
+// Named is a hook defined by gw.hooks.<event>.<name>.* keys:
+//
+//	command       command to run via sh -c (multi-value, run in order)
+//	when-changed  globs, relative to the worktree root, of the files the hook depends on
+//	outputs       paths, relative to the worktree root, the hook produces
+//	after         named hooks of the same event that must succeed first
+//
+// A named hook with when-changed is skipped when it already succeeded for the same
+// inputs. If it has outputs, they are copied from a worktree where it succeeded.
+type Named struct {
+	Name        string
+	Commands    []string
+	WhenChanged []string
+	Outputs     []string
+	After       []string
+}
+
+// LoadNamed returns the named hooks of event, sorted by name. Hooks without a
+// command are ignored.
+func LoadNamed(cwd, event string) []Named {
+	prefix := configKeyForHook(event) + "."
+	entries, _ := gitx.ConfigGetRegexp(cwd, "^"+regexp.QuoteMeta(prefix)+".+\\.")
+	byName := map[string]*Named{}
+	for _, e := range entries {
+		rest := strings.TrimPrefix(e.Key, prefix)
+		i := strings.LastIndex(rest, ".")
+		if i <= 0 {
+			continue
+		}
+		name, field := rest[:i], rest[i+1:]
+		nh := byName[name]
+		if nh == nil {
+			nh = &Named{Name: name}
+			byName[name] = nh
+		}
+		switch field {
+		case "command":
+			if e.Value != "" {
+				nh.Commands = append(nh.Commands, e.Value)
+			}
+		case "when-changed":
+			nh.WhenChanged = append(nh.WhenChanged, strings.Fields(e.Value)...)
+		case "outputs":
+			nh.Outputs = append(nh.Outputs, strings.Fields(e.Value)...)
+		case "after":
+			nh.After = append(nh.After, strings.Fields(strings.ReplaceAll(e.Value, ",", " "))...)
+		}
+	}
+	var res []Named
+	for _, nh := range byName {
+		if len(nh.Commands) > 0 {
+			res = append(res, *nh)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	return res
+}
+
+// inputCache remembers the worktrees in which a named hook succeeded for a given hash
+// of its inputs. Entries live in <repo state>/hook-cache/<event>/<name>/<hash>, one
+// worktree root per line.
+type inputCache struct {
+	root  string
+	hook  Named
+	hash  string
+	entry string
+}
+
+// newInputCache returns nil when the hook has no when-changed globs.
+func newInputCache(root, event string, nh Named) (*inputCache, error) {
+	if len(nh.WhenChanged) == 0 {
+		return nil, nil
+	}
+	hash, err := hashInputs(root, nh)
+	if err != nil {
+		return nil, err
+	}
+	repoDir, err := state.RepoDir(root)
+	if err != nil {
+		return nil, err
+	}
+	entry := filepath.Join(repoDir, "hook-cache", event, nh.Name, hash)
+	return &inputCache{root: root, hook: nh, hash: hash, entry: entry}, nil
+}
+
+// hashInputs hashes the hook's commands and the paths and contents of the files under
+// root matching its when-changed globs.
+func hashInputs(root string, nh Named) (string, error) {
+	seen := map[string]bool{}
+	var files []string
+	fsys := os.DirFS(root)
+	for _, pattern := range nh.WhenChanged {
+		matches, err := doublestar.Glob(fsys, pattern, doublestar.WithFilesOnly())
+		if err != nil {
+			return "", fmt.Errorf("invalid when-changed pattern %q: %w", pattern, err)
+		}
+		for _, m := range matches {
+			if !seen[m] {
+				seen[m] = true
+				files = append(files, m)
+			}
+		}
+	}
+	sort.Strings(files)
+
+	h := sha256.New()
+	for _, c := range nh.Commands {
+		fmt.Fprintf(h, "cmd\x00%s\x00", c)
+	}
+	for _, rel := range files {
+		fmt.Fprintf(h, "file\x00%s\x00", rel)
+		f, err := os.Open(filepath.Join(root, rel))
+		if err != nil {
+			return "", err
+		}
+		_, err = io.Copy(h, f)
+		f.Close()
+		if err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// sources returns the recorded worktree roots, most recent first.
+func (c *inputCache) sources() []string {
+	f, err := os.Open(c.entry)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	var lines []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if ln := strings.TrimSpace(sc.Text()); ln != "" {
+			lines = append(lines, ln)
+		}
+	}
+	var res []string
+	seen := map[string]bool{}
+	for i := len(lines) - 1; i >= 0; i-- {
+		if !seen[lines[i]] {
+			seen[lines[i]] = true
+			res = append(res, lines[i])
+		}
+	}
+	return res
+}
+
+// reuse reports whether the hook can be skipped, copying its outputs from a worktree
+// where it succeeded with the same inputs when needed. msg describes what happened.
+func (c *inputCache) reuse() (msg string, ok bool) {
+	srcs := c.sources()
+	if len(srcs) == 0 {
+		return "", false
+	}
+	if len(c.hook.Outputs) == 0 {
+		return "inputs unchanged, skipped", true
+	}
+	for _, src := range srcs {
+		if !c.outputsExist(src) {
+			continue
+		}
+		if fsutil.SamePath(src, c.root) {
+			return "inputs unchanged, skipped", true
+		}
+		// The source may have changed its inputs since it ran the hook.
+		if h, err := hashInputs(src, c.hook); err != nil || h != c.hash {
+			continue
+		}
+		if err := c.copyOutputs(src); err != nil {
+			continue
+		}
+		return fmt.Sprintf("inputs unchanged, copied %s from %s", strings.Join(c.hook.Outputs, ", "), src), true
+	}
+	return "", false
+}
+
+func (c *inputCache) outputsExist(root string) bool {
+	for _, o := range c.hook.Outputs {
+		if _, err := os.Lstat(filepath.Join(root, o)); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *inputCache) copyOutputs(src string) error {
+	for _, o := range c.hook.Outputs {
+		from := filepath.Join(src, o)
+		to := filepath.Join(c.root, o)
+		fi, err := os.Lstat(from)
+		if err != nil {
+			return err
+		}
+		if err := os.RemoveAll(to); err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			err = fsutil.CopyDir(from, to)
+		} else {
+			err = fsutil.CopyFile(from, to)
+		}
+		if err != nil {
+			os.RemoveAll(to)
+			return err
+		}
+	}
+	return nil
+}
+
+// record remembers that the hook succeeded in c.root.
+func (c *inputCache) record() error {
+	if err := os.MkdirAll(filepath.Dir(c.entry), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(c.entry, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = fmt.Fprintln(f, c.root)
+	return err
+}
+
+// recordScript is the shell equivalent of record, run by background hooks on success.
+func (c *inputCache) recordScript() string {
+	return fmt.Sprintf("mkdir -p %s && echo %s >> %s", shellQuote(filepath.Dir(c.entry)), shellQuote(c.root), shellQuote(c.entry))
+}
@@ -0,0 +1,122 @@
+package hooks
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func gitIn(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	args = append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+func countLines(t *testing.T, path string) int {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	return len(strings.Split(strings.TrimSpace(string(data)), "\n"))
+}
+
+func TestLoadNamed_shouldParseNamedHooks(t *testing.T) {
+	tDir := initHookTestRepo(t,
+		[2]string{"gw.hooks.post-create", "echo plain"},
+		[2]string{"gw.hooks.post-create.timeout", "10"},
+		[2]string{"gw.hooks.post-create.npm.command", "npm ci"},
+		[2]string{"gw.hooks.post-create.npm.when-changed", "package-lock.json"},
+		[2]string{"gw.hooks.post-create.npm.outputs", "node_modules"},
+		[2]string{"gw.hooks.post-create.go.command", "go mod download"},
+		[2]string{"gw.hooks.post-create.go.when-changed", "go.sum **/go.sum"},
+		[2]string{"gw.hooks.post-create.empty.when-changed", "x"},
+		[2]string{"gw.hooks.post-remove.other.command", "true"},
+	)
+
+	got := LoadNamed(tDir, PostCreate)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 named hooks, got %+v", got)
+	}
+	if got[0].Name != "go" || strings.Join(got[0].WhenChanged, ",") != "go.sum,**/go.sum" {
+		t.Fatalf("unexpected go hook: %+v", got[0])
+	}
+	if got[1].Name != "npm" || got[1].Commands[0] != "npm ci" || got[1].Outputs[0] != "node_modules" {
+		t.Fatalf("unexpected npm hook: %+v", got[1])
+	}
+}
+
+func TestRunHook_shouldSkipNamedHook_whenInputsUnchanged(t *testing.T) {
+	counter := filepath.Join(t.TempDir(), "runs")
+	tDir := initHookTestRepo(t,
+		[2]string{"gw.hooks.post-create.deps.command", "echo run >> " + counter},
+		[2]string{"gw.hooks.post-create.deps.when-changed", "*.lock"},
+	)
+	lock := filepath.Join(tDir, "deps.lock")
+	if err := os.WriteFile(lock, []byte("v1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := RunHook(tDir, PostCreate, nil, Options{Output: io.Discard}); err != nil {
+			t.Fatalf("RunHook: %v", err)
+		}
+	}
+	if n := countLines(t, counter); n != 1 {
+		t.Fatalf("expected hook to run once, ran %d times", n)
+	}
+
+	if err := os.WriteFile(lock, []byte("v2"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RunHook(tDir, PostCreate, nil, Options{Output: io.Discard}); err != nil {
+		t.Fatalf("RunHook: %v", err)
+	}
+	if n := countLines(t, counter); n != 2 {
+		t.Fatalf("expected hook to run again after inputs changed, ran %d times", n)
+	}
+}
+
+func TestRunHook_shouldCopyOutputs_whenAnotherWorktreeHasThem(t *testing.T) {
+	counter := filepath.Join(t.TempDir(), "runs")
+	tDir, _ := filepath.EvalSymlinks(initHookTestRepo(t,
+		[2]string{"gw.hooks.post-create.npm.command", "mkdir -p node_modules/.bin && echo built > node_modules/marker && ln -s ../marker node_modules/.bin/link && echo run >> " + counter},
+		[2]string{"gw.hooks.post-create.npm.when-changed", "package-lock.json"},
+		[2]string{"gw.hooks.post-create.npm.outputs", "node_modules"},
+	))
+	if err := os.WriteFile(filepath.Join(tDir, "package-lock.json"), []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tDir, ".gitignore"), []byte("node_modules\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gitIn(t, tDir, "add", ".")
+	gitIn(t, tDir, "commit", "-m", "init")
+
+	if _, err := RunHook(tDir, PostCreate, nil, Options{Output: io.Discard}); err != nil {
+		t.Fatalf("RunHook in primary: %v", err)
+	}
+
+	wt := filepath.Join(tDir, "wt")
+	gitIn(t, tDir, "worktree", "add", "-b", "feature", wt)
+	if _, err := RunHook(wt, PostCreate, nil, Options{Output: io.Discard}); err != nil {
+		t.Fatalf("RunHook in worktree: %v", err)
+	}
+	if n := countLines(t, counter); n != 1 {
+		t.Fatalf("expected hook to run once, ran %d times", n)
+	}
+	data, err := os.ReadFile(filepath.Join(wt, "node_modules", "marker"))
+	if err != nil || strings.TrimSpace(string(data)) != "built" {
+		t.Fatalf("expected copied output, got %q err=%v", data, err)
+	}
+	if link, err := os.Readlink(filepath.Join(wt, "node_modules", ".bin", "link")); err != nil || link != "../marker" {
+		t.Fatalf("expected symlink to be preserved, got %q err=%v", link, err)
+	}
+}
@@ -0,0 +1,154 @@
+package hooks
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sh0o0/gw/internal/gitx"
+)
+
+// FailurePolicy decides what happens when a hook command fails.
+type FailurePolicy string
+
+const (
+	// FailAbort stops the remaining commands and asks the caller to abort the operation.
+	FailAbort FailurePolicy = "abort"
+	// FailWarn runs the remaining commands and reports the failure.
+	FailWarn FailurePolicy = "warn"
+	// FailIgnore runs the remaining commands and reports nothing.
+	FailIgnore FailurePolicy = "ignore"
+)
+
+// Settings control how the commands of a hook are executed.
+type Settings struct {
+	// Timeout kills the command's process group when exceeded. Zero means no timeout.
+	Timeout time.Duration
+	// Retries is the number of extra attempts after a failed (not timed out) run.
+	Retries int
+	// OnFailure is the policy applied once all attempts failed.
+	OnFailure FailurePolicy
+}
+
+// DefaultSettings returns the settings used when nothing is configured:
+// no timeout, no retries, abort for pre-* hooks and warn otherwise.
+func DefaultSettings(name string) Settings {
+	s := Settings{OnFailure: FailWarn}
+	if IsPre(name) {
+		s.OnFailure = FailAbort
+	}
+	return s
+}
+
+// LoadSettings reads gw.hooks.{timeout,retries,on-failure}, overridden per event by
+// gw.hooks.<name>.{timeout,retries,on-failure}.
+func LoadSettings(cwd, name string) Settings {
+	return loadSettings(cwd, name, "gw.hooks.", configKeyForHook(name)+".")
+}
+
+// loadNamedSettings extends LoadSettings with gw.hooks.<event>.<hook>.{timeout,retries,on-failure}.
+func loadNamedSettings(cwd, event, hook string) Settings {
+	return loadSettings(cwd, event, "gw.hooks.", configKeyForHook(event)+".", configKeyForHook(event)+"."+hook+".")
+}
+
+func loadSettings(cwd, name string, prefixes ...string) Settings {
+	s := DefaultSettings(name)
+	for _, prefix := range prefixes {
+		if v, err := gitx.ConfigGet(cwd, prefix+"timeout"); err == nil {
+			if d, err := ParseTimeout(v); err == nil {
+				s.Timeout = d
+			}
+		}
+		if v, err := gitx.ConfigGet(cwd, prefix+"retries"); err == nil {
+			if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+				s.Retries = n
+			}
+		}
+		if v, err := gitx.ConfigGet(cwd, prefix+"on-failure"); err == nil {
+			if p, err := ParseFailurePolicy(v); err == nil {
+				s.OnFailure = p
+			}
+		}
+	}
+	return s
+}
+
+// ParseTimeout accepts a Go duration ("90s", "5m") or a plain number of seconds.
+func ParseTimeout(v string) (time.Duration, error) {
+	v = strings.TrimSpace(v)
+	if n, err := strconv.Atoi(v); err == nil {
+		if n < 0 {
+			return 0, fmt.Errorf("invalid timeout: %s", v)
+		}
+		return time.Duration(n) * time.Second, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid timeout: %s", v)
+	}
+	return d, nil
+}
+
+func ParseFailurePolicy(v string) (FailurePolicy, error) {
+	switch p := FailurePolicy(strings.ToLower(strings.TrimSpace(v))); p {
+	case FailAbort, FailWarn, FailIgnore:
+		return p, nil
+	default:
+		return "", fmt.Errorf("invalid on-failure policy: %s (want abort, warn or ignore)", v)
+	}
+}
+
+// Error describes a failed hook command.
+type Error struct {
+	Hook     string
+	Command  string
+	Attempts int
+	ExitCode int
+	TimedOut bool
+	Timeout  time.Duration
+	Policy   FailurePolicy
+	Err      error
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("%s hook %q", e.Hook, e.Command)
+	switch {
+	case e.TimedOut:
+		msg += fmt.Sprintf(" timed out after %s (process group killed)", e.Timeout)
+	case e.ExitCode >= 0:
+		msg += fmt.Sprintf(" exited with status %d", e.ExitCode)
+	default:
+		msg += fmt.Sprintf(" failed: %v", e.Err)
+	}
+	if e.Attempts > 1 {
+		msg += fmt.Sprintf(" after %d attempts", e.Attempts)
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Aborts reports whether err returned by RunHook should abort the calling operation.
+func Aborts(err error) bool {
+	if err == nil {
+		return false
+	}
+	var gerr *GroupError
+	if errors.As(err, &gerr) {
+		for _, f := range gerr.Failed {
+			if f.Policy == FailAbort {
+				return true
+			}
+		}
+		return false
+	}
+	var herr *Error
+	if errors.As(err, &herr) {
+		return herr.Policy == FailAbort
+	}
+	return true
+}
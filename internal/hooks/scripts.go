@@ -0,0 +1,168 @@
+package hooks
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sh0o0/gw/internal/gitx"
+	"github.com/sh0o0/gw/internal/state"
+)
+
+// ScriptsDir holds hook scripts committed to the repository, relative to the worktree
+// root. Executable files in <ScriptsDir>/<event>.d run after the config hooks of event.
+const ScriptsDir = ".gw/hooks"
+
+// TrustFunc asks the user whether the repository's hook scripts may run.
+// scripts are all hook scripts of the repository, relative to the worktree root.
+type TrustFunc func(scripts []string) bool
+
+// Scripts returns the executable files in <root>/.gw/hooks/<name>.d, sorted by name.
+func Scripts(root, name string) []string {
+	dir := filepath.Join(root, ScriptsDir, name+".d")
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var res []string
+	for _, e := range entries {
+		p := filepath.Join(dir, e.Name())
+		if isExecutableFile(p) {
+			res = append(res, p)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+func isExecutableFile(p string) bool {
+	fi, err := os.Stat(p)
+	return err == nil && fi.Mode().IsRegular() && fi.Mode().Perm()&0o111 != 0
+}
+
+// allScripts returns every hook script of the repository, relative to root.
+func allScripts(root string) []string {
+	dirs, _ := filepath.Glob(filepath.Join(root, ScriptsDir, "*.d"))
+	var res []string
+	for _, d := range dirs {
+		for _, p := range Scripts(root, strings.TrimSuffix(filepath.Base(d), ".d")) {
+			if rel, err := filepath.Rel(root, p); err == nil {
+				res = append(res, rel)
+			}
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// ScriptsHash hashes the names, modes and contents of all hook scripts under root,
+// so that any change to any script requires trusting them again.
+func ScriptsHash(root string) (string, error) {
+	h := sha256.New()
+	for _, rel := range allScripts(root) {
+		p := filepath.Join(root, rel)
+		fi, err := os.Stat(p)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%s\x00%o\x00", rel, fi.Mode().Perm())
+		f, err := os.Open(p)
+		if err != nil {
+			return "", err
+		}
+		_, err = io.Copy(h, f)
+		f.Close()
+		if err != nil {
+			return "", err
+		}
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// trustFile lists the trusted script hashes of the repository containing cwd, one per line.
+func trustFile(cwd string) (string, error) {
+	dir, err := state.RepoDir(cwd)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "trusted-hooks"), nil
+}
+
+// IsTrusted reports whether hash has been trusted for the repository containing cwd.
+func IsTrusted(cwd, hash string) bool {
+	path, err := trustFile(cwd)
+	if err != nil {
+		return false
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if strings.TrimSpace(sc.Text()) == hash {
+			return true
+		}
+	}
+	return false
+}
+
+// Trust records hash as trusted for the repository containing cwd. Several hashes are
+// kept so worktrees on branches with different script versions do not re-prompt.
+func Trust(cwd, hash string) error {
+	if IsTrusted(cwd, hash) {
+		return nil
+	}
+	path, err := trustFile(cwd)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = fmt.Fprintln(f, hash)
+	return err
+}
+
+// trustedScripts returns the hook scripts of name in the worktree containing dir when
+// they are trusted, asking through trust (if set) when they are not. Untrusted scripts
+// are skipped with a note on w.
+func trustedScripts(dir, name string, trust TrustFunc, w io.Writer) []string {
+	root, err := gitx.Root(dir)
+	if err != nil {
+		return nil
+	}
+	scripts := Scripts(root, name)
+	if len(scripts) == 0 {
+		return nil
+	}
+	hash, err := ScriptsHash(root)
+	if err != nil {
+		fmt.Fprintf(w, "hook %s: cannot read %s: %v\n", name, ScriptsDir, err)
+		return nil
+	}
+	if IsTrusted(root, hash) {
+		return scripts
+	}
+	if trust == nil || !trust(allScripts(root)) {
+		fmt.Fprintf(w, "hook %s: skipping untrusted scripts in %s\n", name, filepath.Join(root, ScriptsDir))
+		return nil
+	}
+	if err := Trust(root, hash); err != nil {
+		fmt.Fprintf(w, "hook %s: failed to record trust: %v\n", name, err)
+	}
+	return scripts
+}
@@ -0,0 +1,401 @@
+package jobs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/sh0o0/gw/internal/state"
+)
+
+// ExitFileEnv names the environment variable holding the file a job's exit status is
+// written to. Commands that kill their own process group (e.g. hook timeouts) can
+// write a status there first so the job is not reported as lost.
+const ExitFileEnv = "GW_JOB_EXIT_FILE"
+
+// Markers written to the exit file instead of an exit code. The exit file is never
+// overwritten, so whichever of the exit code and a marker comes first wins.
+const (
+	// TimeoutMarker records that the job was stopped by its timeout.
+	TimeoutMarker = "timeout"
+	killedMarker  = "killed"
+)
+
+// pruneAfter is how long finished jobs are kept in the registry.
+const pruneAfter = 7 * 24 * time.Hour
+
+type Status string
+
+const (
+	StatusRunning Status = "running"
+	StatusExited  Status = "exited"
+	StatusKilled  Status = "killed"
+	// StatusTimedOut means the job was stopped by its own timeout.
+	StatusTimedOut Status = "timed out"
+	// StatusLost means the process is gone without having recorded an exit status.
+	StatusLost Status = "lost"
+)
+
+// Job is a detached process started by gw.
+type Job struct {
+	ID        string    `json:"id"`
+	PID       int       `json:"pid"`
+	Command   string    `json:"command"`
+	Dir       string    `json:"dir"`
+	LogPath   string    `json:"log_path"`
+	StartedAt time.Time `json:"started_at"`
+	// ProcStart is the process start time from /proc, used to tell the job's process
+	// from a later one that reused its PID. Empty where /proc is unavailable.
+	ProcStart string `json:"proc_start,omitempty"`
+
+	Status     Status    `json:"-"`
+	ExitCode   int       `json:"-"`
+	FinishedAt time.Time `json:"-"`
+
+	registry string
+}
+
+// Spec describes a process to start with Start.
+type Spec struct {
+	// Args is the command and its arguments.
+	Args []string
+	// Label is shown by `gw jobs`; defaults to Args joined by spaces.
+	Label string
+	Dir   string
+	// Env is the full environment; nil means the current process environment.
+	Env []string
+	// LogPath receives stdout and stderr (appended). Empty discards output.
+	LogPath string
+}
+
+// Dir returns the job registry of the repository containing cwd.
+func Dir(cwd string) (string, error) {
+	repoDir, err := state.RepoDir(cwd)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(repoDir, "jobs"), nil
+}
+
+// Start launches spec detached in its own process group and records it in the registry
+// of the repository containing cwd. The exit status is written when the process ends.
+func Start(cwd string, spec Spec) (*Job, error) {
+	if len(spec.Args) == 0 {
+		return nil, errors.New("job command required")
+	}
+	registry, err := Dir(cwd)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(registry, 0o755); err != nil {
+		return nil, err
+	}
+	id, err := reserveID(registry)
+	if err != nil {
+		return nil, err
+	}
+	job := &Job{
+		ID:        id,
+		Command:   spec.Label,
+		Dir:       spec.Dir,
+		LogPath:   spec.LogPath,
+		StartedAt: time.Now(),
+		registry:  registry,
+	}
+	if job.Command == "" {
+		job.Command = strings.Join(spec.Args, " ")
+	}
+
+	exitFile := job.ExitFile()
+	// The wrapper records the exit status of the command unless a marker was written
+	// first; "$0" is the exit file.
+	wrapper := `"$@"; rc=$?; { set -C; echo $rc > "$0"; } 2>/dev/null`
+	cmd := exec.Command("sh", append([]string{"-c", wrapper, exitFile}, spec.Args...)...)
+	cmd.Dir = spec.Dir
+	env := spec.Env
+	if env == nil {
+		env = os.Environ()
+	}
+	cmd.Env = append(env, ExitFileEnv+"="+exitFile)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Stdin = nil
+	if spec.LogPath != "" {
+		logFile, err := state.OpenLog(spec.LogPath)
+		if err != nil {
+			os.Remove(job.recordFile())
+			return nil, err
+		}
+		defer logFile.Close()
+		cmd.Stdout = logFile
+		cmd.Stderr = logFile
+	}
+	if err := cmd.Start(); err != nil {
+		os.Remove(job.recordFile())
+		return nil, err
+	}
+	job.PID = cmd.Process.Pid
+	job.ProcStart = procStartTime(job.PID)
+	job.Status = StatusRunning
+	_ = cmd.Process.Release()
+	if err := job.save(); err != nil {
+		return job, err
+	}
+	return job, nil
+}
+
+// reserveID claims the next sequential job id by creating its record file exclusively.
+func reserveID(registry string) (string, error) {
+	next := 1
+	for _, id := range recordIDs(registry) {
+		if n, err := strconv.Atoi(id); err == nil && n >= next {
+			next = n + 1
+		}
+	}
+	for i := 0; i < 100; i++ {
+		id := strconv.Itoa(next + i)
+		f, err := os.OpenFile(filepath.Join(registry, id+".json"), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			f.Close()
+			return id, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return "", err
+		}
+	}
+	return "", errors.New("could not allocate job id")
+}
+
+func recordIDs(registry string) []string {
+	matches, _ := filepath.Glob(filepath.Join(registry, "*.json"))
+	ids := make([]string, 0, len(matches))
+	for _, m := range matches {
+		ids = append(ids, strings.TrimSuffix(filepath.Base(m), ".json"))
+	}
+	return ids
+}
+
+func (j *Job) recordFile() string {
+	return filepath.Join(j.registry, j.ID+".json")
+}
+
+// ExitFile is where the job's exit status is written once it finishes.
+func (j *Job) ExitFile() string {
+	return filepath.Join(j.registry, j.ID+".exit")
+}
+
+func (j *Job) save() error {
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(j.recordFile(), data, 0o644)
+}
+
+// Get loads job id from the registry of the repository containing cwd.
+func Get(cwd, id string) (*Job, error) {
+	registry, err := Dir(cwd)
+	if err != nil {
+		return nil, err
+	}
+	return load(registry, id)
+}
+
+func load(registry, id string) (*Job, error) {
+	data, err := os.ReadFile(filepath.Join(registry, id+".json"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("no such job: %s", id)
+		}
+		return nil, err
+	}
+	job := &Job{registry: registry}
+	if err := json.Unmarshal(data, job); err != nil {
+		return nil, fmt.Errorf("corrupt job record %s: %w", id, err)
+	}
+	job.refresh()
+	return job, nil
+}
+
+// List returns all jobs of the repository containing cwd, newest first.
+// Finished jobs older than a week are pruned from the registry.
+func List(cwd string) ([]*Job, error) {
+	registry, err := Dir(cwd)
+	if err != nil {
+		return nil, err
+	}
+	var res []*Job
+	for _, id := range recordIDs(registry) {
+		job, err := load(registry, id)
+		if err != nil {
+			continue
+		}
+		if job.Status != StatusRunning && time.Since(job.StartedAt) > pruneAfter {
+			job.remove()
+			continue
+		}
+		res = append(res, job)
+	}
+	sort.Slice(res, func(i, k int) bool {
+		return res[i].StartedAt.After(res[k].StartedAt)
+	})
+	return res, nil
+}
+
+// refresh updates Status, ExitCode and FinishedAt from the exit file and the process table.
+func (j *Job) refresh() {
+	if fi, err := os.Stat(j.ExitFile()); err == nil {
+		data, _ := os.ReadFile(j.ExitFile())
+		text := strings.TrimSpace(string(data))
+		if text == "" && j.alive() {
+			// The status is still being written.
+			j.Status = StatusRunning
+			return
+		}
+		if text == killedMarker {
+			j.Status = StatusKilled
+			j.ExitCode = -1
+		} else if text == TimeoutMarker {
+			j.Status = StatusTimedOut
+			j.ExitCode = -1
+		} else if code, err := strconv.Atoi(text); err == nil {
+			j.Status = StatusExited
+			j.ExitCode = code
+		} else {
+			j.Status = StatusLost
+			j.ExitCode = -1
+		}
+		j.FinishedAt = fi.ModTime()
+		return
+	}
+	if j.alive() {
+		j.Status = StatusRunning
+		return
+	}
+	// The process may have just written its exit file.
+	if _, err := os.Stat(j.ExitFile()); err == nil {
+		j.refresh()
+		return
+	}
+	j.Status = StatusLost
+	j.ExitCode = -1
+}
+
+// alive reports whether the job's own process is still running, not merely some
+// process that was given the same PID after it died without recording a status.
+func (j *Job) alive() bool {
+	if !processAlive(j.PID) {
+		return false
+	}
+	if j.ProcStart != "" {
+		return procStartTime(j.PID) == j.ProcStart
+	}
+	// Without a recorded start time, rely on the job leading its own process group.
+	pgid, err := syscall.Getpgid(j.PID)
+	return err == nil && pgid == j.PID
+}
+
+// procStartTime returns the start time of pid in clock ticks since boot (field 22 of
+// /proc/<pid>/stat), or "" where /proc is unavailable.
+func procStartTime(pid int) string {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return ""
+	}
+	// Fields after the parenthesised command name start at field 3.
+	i := strings.LastIndexByte(string(data), ')')
+	if i < 0 {
+		return ""
+	}
+	fields := strings.Fields(string(data[i+1:]))
+	if len(fields) < 20 {
+		return ""
+	}
+	return fields[19]
+}
+
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	err := syscall.Kill(pid, 0)
+	if err != nil && !errors.Is(err, syscall.EPERM) {
+		return false
+	}
+	// A zombie has exited but not been reaped yet; /proc is only available on Linux.
+	if data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid)); err == nil {
+		if i := strings.LastIndexByte(string(data), ')'); i >= 0 && i+2 < len(data) {
+			return data[i+2] != 'Z'
+		}
+	}
+	return true
+}
+
+// Wait blocks until the job is no longer running and returns its final state.
+func (j *Job) Wait(poll time.Duration) *Job {
+	for {
+		j.refresh()
+		if j.Status != StatusRunning {
+			return j
+		}
+		time.Sleep(poll)
+	}
+}
+
+// Kill terminates the job's whole process group and records it as killed. It fails if
+// the job finished on its own before it could be killed.
+func (j *Job) Kill() error {
+	j.refresh()
+	if j.Status != StatusRunning {
+		return fmt.Errorf("job %s is not running (%s)", j.ID, j.Status)
+	}
+	return j.killGroup()
+}
+
+// killGroup signals the process group and writes the killed marker unless the job
+// recorded its own status first. Nothing is signalled once the job's own process is
+// gone, so a group that reused its PID is left alone.
+func (j *Job) killGroup() error {
+	if j.alive() {
+		if err := syscall.Kill(-j.PID, syscall.SIGTERM); err != nil && !errors.Is(err, syscall.ESRCH) {
+			return err
+		}
+	}
+	deadline := time.Now().Add(3 * time.Second)
+	for j.alive() && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	if j.alive() {
+		_ = syscall.Kill(-j.PID, syscall.SIGKILL)
+	}
+	f, err := os.OpenFile(j.ExitFile(), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		if errors.Is(err, os.ErrExist) {
+			// The job recorded its own status before the signal arrived.
+			j.refresh()
+			return fmt.Errorf("job %s finished before it was killed (%s)", j.ID, j.Status)
+		}
+		return err
+	}
+	_, err = f.WriteString(killedMarker + "\n")
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	j.refresh()
+	return nil
+}
+
+func (j *Job) remove() {
+	os.Remove(j.recordFile())
+	os.Remove(j.ExitFile())
+}
@@ -0,0 +1,198 @@
+package jobs
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func initJobsTestRepo(t *testing.T) string {
+	t.Helper()
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	dir := t.TempDir()
+	cmd := exec.Command("git", "init")
+	cmd.Dir = dir
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("git init: %v", err)
+	}
+	return dir
+}
+
+func TestStart_shouldRecordExitStatusAndLog(t *testing.T) {
+	dir := initJobsTestRepo(t)
+	logPath := filepath.Join(t.TempDir(), "job.log")
+
+	job, err := Start(dir, Spec{Args: []string{"sh", "-c", "echo hello; exit 3"}, Dir: dir, LogPath: logPath})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if job.ID != "1" || job.PID <= 0 {
+		t.Fatalf("unexpected job: %+v", job)
+	}
+
+	got, err := Get(dir, job.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	got.Wait(10 * time.Millisecond)
+	if got.Status != StatusExited || got.ExitCode != 3 {
+		t.Fatalf("expected exit 3, got %s %d", got.Status, got.ExitCode)
+	}
+	data, _ := os.ReadFile(logPath)
+	if strings.TrimSpace(string(data)) != "hello" {
+		t.Fatalf("unexpected log: %q", data)
+	}
+	if got.Command != "sh -c echo hello; exit 3" {
+		t.Fatalf("unexpected command: %s", got.Command)
+	}
+}
+
+func TestList_shouldReturnNewestFirst(t *testing.T) {
+	dir := initJobsTestRepo(t)
+	for i := 0; i < 2; i++ {
+		if _, err := Start(dir, Spec{Args: []string{"true"}, Label: "job"}); err != nil {
+			t.Fatalf("Start: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	list, err := List(dir)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(list) != 2 || list[0].ID != "2" || list[1].ID != "1" {
+		t.Fatalf("unexpected list: %+v", list)
+	}
+}
+
+func TestKill_shouldStopProcessGroup(t *testing.T) {
+	dir := initJobsTestRepo(t)
+	job, err := Start(dir, Spec{Args: []string{"sh", "-c", "sleep 30 & sleep 30"}})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if err := job.Kill(); err != nil {
+		t.Fatalf("Kill: %v", err)
+	}
+	if job.Status != StatusKilled {
+		t.Fatalf("expected killed, got %s", job.Status)
+	}
+	if err := job.Kill(); err == nil {
+		t.Fatalf("expected error when killing a finished job")
+	}
+}
+
+func TestRefresh_shouldReportLost_whenProcessGoneWithoutExitFile(t *testing.T) {
+	dir := initJobsTestRepo(t)
+	registry, err := Dir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(registry, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	job := &Job{ID: "7", PID: 1 << 22, StartedAt: time.Now(), registry: registry}
+	if err := job.save(); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Get(dir, "7")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Status != StatusLost {
+		t.Fatalf("expected lost, got %s", got.Status)
+	}
+}
+
+func TestStart_shouldReportExitCode124AsExited(t *testing.T) {
+	dir := initJobsTestRepo(t)
+	job, err := Start(dir, Spec{Args: []string{"sh", "-c", "exit 124"}})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	got := job.Wait(10 * time.Millisecond)
+	if got.Status != StatusExited || got.ExitCode != 124 {
+		t.Fatalf("expected exit 124, got %s %d", got.Status, got.ExitCode)
+	}
+}
+
+func TestStart_shouldKeepMarker_whenWrittenBeforeExit(t *testing.T) {
+	dir := initJobsTestRepo(t)
+	job, err := Start(dir, Spec{Args: []string{"sh", "-c", `echo ` + TimeoutMarker + ` > "$` + ExitFileEnv + `"; exit 3`}})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	got := job.Wait(10 * time.Millisecond)
+	if got.Status != StatusTimedOut {
+		t.Fatalf("expected timed out, got %s %d", got.Status, got.ExitCode)
+	}
+	// Let the wrapper try to record exit 3 before the registry is cleaned up.
+	for job.alive() {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got, _ := Get(dir, job.ID); got.Status != StatusTimedOut {
+		t.Fatalf("expected the marker to be kept, got %s %d", got.Status, got.ExitCode)
+	}
+}
+
+func TestKill_shouldNotOverwriteExitFile_whenJobAlreadyFinished(t *testing.T) {
+	dir := initJobsTestRepo(t)
+	job, err := Start(dir, Spec{Args: []string{"true"}})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	job.Wait(10 * time.Millisecond)
+	// Pretend the liveness check saw the job just before it finished.
+	job.Status = StatusRunning
+	if err := job.killGroup(); err == nil {
+		t.Fatal("expected killGroup to report that the job already finished")
+	}
+	if got, _ := Get(dir, job.ID); got.Status != StatusExited || got.ExitCode != 0 {
+		t.Fatalf("expected exit 0 to be kept, got %s %d", got.Status, got.ExitCode)
+	}
+}
+
+func TestRefresh_shouldReportLost_whenPIDWasReused(t *testing.T) {
+	dir := initJobsTestRepo(t)
+	registry, err := Dir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(registry, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// An unrelated process group now owns the PID the job once had.
+	other := exec.Command("sleep", "30")
+	other.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := other.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = other.Process.Kill()
+		_ = other.Wait()
+	})
+	start := procStartTime(other.Process.Pid)
+	if start == "" {
+		t.Skip("no /proc on this system")
+	}
+	job := &Job{ID: "8", PID: other.Process.Pid, ProcStart: start + "0", StartedAt: time.Now(), registry: registry}
+	if err := job.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Get(dir, "8")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Status != StatusLost {
+		t.Fatalf("expected lost, got %s", got.Status)
+	}
+	got.Status = StatusRunning
+	_ = got.killGroup()
+	if !processAlive(other.Process.Pid) {
+		t.Fatal("expected the unrelated process to be left alone")
+	}
+}
@@ -0,0 +1,103 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sh0o0/gw/internal/gitx"
+	"github.com/sh0o0/gw/internal/worktree"
+)
+
+// MaxLogSize is the size at which OpenLog rotates a log file.
+const MaxLogSize = 1 << 20
+
+// maxLogBackups is the number of rotated files (<log>.1 ... <log>.N) kept per log.
+const maxLogBackups = 3
+
+// Dir returns the gw state directory: $XDG_STATE_HOME/gw, or ~/.local/state/gw.
+func Dir() (string, error) {
+	if v := os.Getenv("XDG_STATE_HOME"); v != "" && filepath.IsAbs(v) {
+		return filepath.Join(v, "gw"), nil
+	}
+	home := os.Getenv("HOME")
+	if home == "" {
+		return "", errors.New("cannot determine state directory: HOME is not set")
+	}
+	return filepath.Join(home, ".local", "state", "gw"), nil
+}
+
+// RepoDir returns the state directory of the repository containing cwd.
+// It follows the worktree base layout: <Dir>/<domain>/<org>/<repo> when origin is set,
+// otherwise <Dir>/local/<primary worktree path relative to $HOME>.
+func RepoDir(cwd string) (string, error) {
+	base, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	if d, o, r, has, _ := worktree.ParseRemoteURL(cwd); has {
+		return filepath.Join(base, d, o, r), nil
+	}
+	commonDir, err := gitx.CommonGitDir(cwd)
+	if err != nil {
+		return "", err
+	}
+	root := filepath.Dir(commonDir)
+	rel := strings.TrimPrefix(root, os.Getenv("HOME")+"/")
+	return filepath.Join(base, "local", strings.TrimPrefix(rel, "/")), nil
+}
+
+// BranchDir returns the per-branch state directory inside RepoDir.
+func BranchDir(cwd, branch string) (string, error) {
+	repoDir, err := RepoDir(cwd)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(repoDir, BranchKey(branch)), nil
+}
+
+// BranchKey turns a branch name into a single path element, the same way worktree
+// directories are named. Detached worktrees use "detached".
+func BranchKey(branch string) string {
+	if branch == "" || branch == "HEAD" {
+		return "detached"
+	}
+	return strings.ReplaceAll(branch, "/", "-")
+}
+
+// OpenLog opens path for appending, creating parent directories and rotating the
+// file first when it has grown beyond MaxLogSize.
+func OpenLog(path string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	if err := RotateLog(path, MaxLogSize); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+}
+
+// RotateLog shifts path to path.1 (and older backups up by one) when it is larger than limit.
+func RotateLog(path string, limit int64) error {
+	fi, err := os.Stat(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	if fi.Size() <= limit {
+		return nil
+	}
+	for i := maxLogBackups - 1; i >= 1; i-- {
+		src := fmt.Sprintf("%s.%d", path, i)
+		if _, err := os.Stat(src); err == nil {
+			if err := os.Rename(src, fmt.Sprintf("%s.%d", path, i+1)); err != nil {
+				return err
+			}
+		}
+	}
+	return os.Rename(path, path+".1")
+}
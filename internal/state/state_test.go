@@ -0,0 +1,65 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDir_shouldPreferXDGStateHome(t *testing.T) {
+	xdg := t.TempDir()
+	t.Setenv("XDG_STATE_HOME", xdg)
+	got, err := Dir()
+	if err != nil || got != filepath.Join(xdg, "gw") {
+		t.Fatalf("unexpected dir: %s err=%v", got, err)
+	}
+
+	home := t.TempDir()
+	t.Setenv("XDG_STATE_HOME", "")
+	t.Setenv("HOME", home)
+	got, err = Dir()
+	if err != nil || got != filepath.Join(home, ".local", "state", "gw") {
+		t.Fatalf("unexpected fallback dir: %s err=%v", got, err)
+	}
+}
+
+func TestBranchKey_shouldSanitizeBranch(t *testing.T) {
+	if got := BranchKey("feature/foo"); got != "feature-foo" {
+		t.Fatalf("unexpected key: %s", got)
+	}
+	if got := BranchKey(""); got != "detached" {
+		t.Fatalf("unexpected key for detached: %s", got)
+	}
+}
+
+func TestOpenLog_shouldRotate_whenLogTooLarge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "post-create.log")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(strings.Repeat("x", MaxLogSize+1)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path+".1", []byte("older"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := OpenLog(path)
+	if err != nil {
+		t.Fatalf("OpenLog: %v", err)
+	}
+	f.WriteString("fresh")
+	f.Close()
+
+	data, _ := os.ReadFile(path)
+	if string(data) != "fresh" {
+		t.Fatalf("expected fresh log, got %d bytes", len(data))
+	}
+	if fi, err := os.Stat(path + ".1"); err != nil || fi.Size() != MaxLogSize+1 {
+		t.Fatalf("expected rotated log in .1: %v", err)
+	}
+	if data, _ := os.ReadFile(path + ".2"); string(data) != "older" {
+		t.Fatalf("expected older backup shifted to .2, got %q", string(data))
+	}
+}
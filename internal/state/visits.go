@@ -0,0 +1,78 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const visitsFile = "visits.json"
+
+// RecordVisit notes that the worktree at path was switched to at the given time.
+// Worktrees that no longer exist are forgotten.
+func RecordVisit(cwd, path string, at time.Time) error {
+	repoDir, err := RepoDir(cwd)
+	if err != nil {
+		return err
+	}
+	visits, err := readVisits(repoDir)
+	if err != nil {
+		return err
+	}
+	for p := range visits {
+		if _, err := os.Stat(p); errors.Is(err, os.ErrNotExist) {
+			delete(visits, p)
+		}
+	}
+	visits[path] = at.UTC()
+	data, err := json.MarshalIndent(visits, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(repoDir, 0o755); err != nil {
+		return err
+	}
+	// Write and rename so concurrent readers never see a partial file.
+	tmp, err := os.CreateTemp(repoDir, visitsFile+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(repoDir, visitsFile))
+}
+
+// Visits returns when each worktree of the repository containing cwd was last
+// switched to with gw, keyed by worktree path.
+func Visits(cwd string) (map[string]time.Time, error) {
+	repoDir, err := RepoDir(cwd)
+	if err != nil {
+		return nil, err
+	}
+	return readVisits(repoDir)
+}
+
+func readVisits(repoDir string) (map[string]time.Time, error) {
+	visits := map[string]time.Time{}
+	data, err := os.ReadFile(filepath.Join(repoDir, visitsFile))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return visits, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &visits); err != nil {
+		// A corrupt file only loses visit history; start over.
+		return map[string]time.Time{}, nil
+	}
+	return visits, nil
+}
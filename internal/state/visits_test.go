@@ -0,0 +1,49 @@
+package state
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecordVisit_shouldKeepLatestVisitAndForgetRemovedWorktrees(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	repo := t.TempDir()
+	if out, err := exec.Command("git", "init", repo).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, out)
+	}
+	kept := filepath.Join(t.TempDir(), "kept")
+	gone := filepath.Join(t.TempDir(), "gone")
+	for _, d := range []string{kept, gone} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	first := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	second := first.Add(time.Hour)
+	for _, v := range []struct {
+		path string
+		at   time.Time
+	}{{kept, first}, {gone, first}, {kept, second}} {
+		if err := RecordVisit(repo, v.path, v.at); err != nil {
+			t.Fatalf("RecordVisit: %v", err)
+		}
+	}
+	if err := os.RemoveAll(gone); err != nil {
+		t.Fatal(err)
+	}
+	if err := RecordVisit(repo, kept, second); err != nil {
+		t.Fatalf("RecordVisit: %v", err)
+	}
+
+	visits, err := Visits(repo)
+	if err != nil {
+		t.Fatalf("Visits: %v", err)
+	}
+	if len(visits) != 1 || !visits[kept].Equal(second) {
+		t.Fatalf("unexpected visits: %v", visits)
+	}
+}
@@ -0,0 +1,386 @@
+package trash
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sh0o0/gw/internal/gitx"
+	"github.com/sh0o0/gw/internal/state"
+)
+
+const (
+	entryFile     = "entry.json"
+	patchFile     = "changes.patch"
+	untrackedFile = "untracked.tar.gz"
+	// refPrefix holds a ref per entry so the removed branch's commits survive both
+	// branch deletion and git gc.
+	refPrefix = "refs/gw/trash/"
+)
+
+// Entry records a removed worktree.
+type Entry struct {
+	ID        string    `json:"id"`
+	Branch    string    `json:"branch"`
+	Head      string    `json:"head"`
+	Path      string    `json:"path"`
+	Base      string    `json:"base,omitempty"`
+	RemovedAt time.Time `json:"removed_at"`
+	// Patch is true when tracked changes (staged and unstaged) were saved.
+	Patch bool `json:"patch,omitempty"`
+	// Untracked is the number of untracked files saved.
+	Untracked int `json:"untracked,omitempty"`
+
+	dir string
+}
+
+// Dir returns the trash of the repository containing cwd.
+func Dir(cwd string) (string, error) {
+	repoDir, err := state.RepoDir(cwd)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(repoDir, "trash"), nil
+}
+
+// Record saves what is needed to restore the worktree at path before it is removed:
+// its branch, HEAD, base ref and any uncommitted changes, including untracked files.
+func Record(cwd, path, branch, base string) (*Entry, error) {
+	out, err := gitx.Cmd(path, "rev-parse", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	e := &Entry{
+		Branch:    branch,
+		Head:      strings.TrimSpace(out),
+		Path:      path,
+		Base:      base,
+		RemovedAt: time.Now(),
+	}
+	if err := e.reserve(cwd); err != nil {
+		return nil, err
+	}
+	if err := e.saveChanges(); err != nil {
+		e.Drop(cwd)
+		return nil, err
+	}
+	if _, err := gitx.Cmd(cwd, "update-ref", e.Ref(), e.Head); err != nil {
+		e.Drop(cwd)
+		return nil, err
+	}
+	if err := e.save(); err != nil {
+		e.Drop(cwd)
+		return nil, err
+	}
+	return e, nil
+}
+
+// reserve picks a unique ID from the removal time and branch and creates its directory.
+func (e *Entry) reserve(cwd string) error {
+	trash, err := Dir(cwd)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(trash, 0o755); err != nil {
+		return err
+	}
+	base := e.RemovedAt.UTC().Format("20060102-150405") + "-" + state.BranchKey(e.Branch)
+	for i := 1; i < 100; i++ {
+		id := base
+		if i > 1 {
+			id = fmt.Sprintf("%s-%d", base, i)
+		}
+		err := os.Mkdir(filepath.Join(trash, id), 0o755)
+		if err == nil {
+			e.ID, e.dir = id, filepath.Join(trash, id)
+			return nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return err
+		}
+	}
+	return errors.New("could not allocate trash entry")
+}
+
+func (e *Entry) saveChanges() error {
+	diff, err := gitx.Cmd(e.Path, "diff", "--binary", "HEAD")
+	if err != nil {
+		return err
+	}
+	if diff != "" {
+		if err := os.WriteFile(filepath.Join(e.dir, patchFile), []byte(diff), 0o644); err != nil {
+			return err
+		}
+		e.Patch = true
+	}
+	list, err := gitx.Cmd(e.Path, "ls-files", "--others", "--exclude-standard", "-z")
+	if err != nil {
+		return err
+	}
+	var files []string
+	for _, f := range strings.Split(list, "\x00") {
+		// Nested repositories are listed as directories; their contents are not ours.
+		if f != "" && !strings.HasSuffix(f, "/") {
+			files = append(files, f)
+		}
+	}
+	if len(files) == 0 {
+		return nil
+	}
+	if err := writeTarball(filepath.Join(e.dir, untrackedFile), e.Path, files); err != nil {
+		return err
+	}
+	e.Untracked = len(files)
+	return nil
+}
+
+func (e *Entry) save() error {
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(e.dir, entryFile), data, 0o644)
+}
+
+// Ref is the ref keeping the entry's HEAD reachable.
+func (e *Entry) Ref() string {
+	return refPrefix + e.ID
+}
+
+// Drop deletes the entry and its ref.
+func (e *Entry) Drop(cwd string) error {
+	if e.ID != "" {
+		_, _ = gitx.Cmd(cwd, "update-ref", "-d", e.Ref())
+	}
+	if e.dir == "" {
+		return nil
+	}
+	return os.RemoveAll(e.dir)
+}
+
+// List returns the entries of the repository containing cwd, newest first.
+func List(cwd string) ([]*Entry, error) {
+	trash, err := Dir(cwd)
+	if err != nil {
+		return nil, err
+	}
+	dirs, err := os.ReadDir(trash)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var res []*Entry
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+		dir := filepath.Join(trash, d.Name())
+		data, err := os.ReadFile(filepath.Join(dir, entryFile))
+		if err != nil {
+			continue
+		}
+		e := &Entry{dir: dir}
+		if json.Unmarshal(data, e) != nil {
+			continue
+		}
+		res = append(res, e)
+	}
+	sort.Slice(res, func(i, k int) bool {
+		return res[i].RemovedAt.After(res[k].RemovedAt)
+	})
+	return res, nil
+}
+
+// Find returns the entry with the given ID, or else the newest entry of that branch.
+func Find(cwd, key string) (*Entry, error) {
+	entries, err := List(cwd)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if e.ID == key {
+			return e, nil
+		}
+	}
+	for _, e := range entries {
+		if e.Branch == key {
+			return e, nil
+		}
+	}
+	return nil, fmt.Errorf("nothing in trash for: %s", key)
+}
+
+// Purge drops the entries removed before cutoff and returns them.
+func Purge(cwd string, cutoff time.Time) ([]*Entry, error) {
+	entries, err := List(cwd)
+	if err != nil {
+		return nil, err
+	}
+	var purged []*Entry
+	for _, e := range entries {
+		if !e.RemovedAt.Before(cutoff) {
+			continue
+		}
+		if err := e.Drop(cwd); err != nil {
+			return purged, err
+		}
+		purged = append(purged, e)
+	}
+	return purged, nil
+}
+
+// Restore recreates the worktree of e at path (its original path when empty) and
+// reapplies the saved changes, then drops the entry. The branch is recreated at the
+// recorded HEAD unless it still exists, in which case it is checked out as is.
+// Restored tracked changes are left unstaged.
+func Restore(cwd string, e *Entry, path string) (string, error) {
+	if path == "" {
+		path = e.Path
+	}
+	if _, err := os.Lstat(path); err == nil {
+		return "", fmt.Errorf("path already exists: %s", path)
+	}
+	var args []string
+	switch {
+	case e.Branch == "":
+		args = []string{"worktree", "add", "--detach", path, e.Head}
+	case branchExists(cwd, e.Branch):
+		args = []string{"worktree", "add", path, e.Branch}
+	default:
+		args = []string{"worktree", "add", "-b", e.Branch, path, e.Head}
+	}
+	if _, err := gitx.Cmd(cwd, args...); err != nil {
+		return "", err
+	}
+	if e.Patch {
+		if _, err := gitx.Cmd(path, "apply", "--binary", "--whitespace=nowarn", filepath.Join(e.dir, patchFile)); err != nil {
+			return path, fmt.Errorf("failed to reapply changes (kept in %s): %w", e.dir, err)
+		}
+	}
+	if e.Untracked > 0 {
+		if err := extractTarball(filepath.Join(e.dir, untrackedFile), path); err != nil {
+			return path, fmt.Errorf("failed to restore untracked files (kept in %s): %w", e.dir, err)
+		}
+	}
+	return path, e.Drop(cwd)
+}
+
+func branchExists(cwd, branch string) bool {
+	_, err := gitx.Cmd(cwd, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch)
+	return err == nil
+}
+
+func writeTarball(dst, root string, files []string) (err error) {
+	f, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for _, rel := range files {
+		if err := addToTar(tw, root, rel); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func addToTar(tw *tar.Writer, root, rel string) error {
+	p := filepath.Join(root, rel)
+	fi, err := os.Lstat(p)
+	if err != nil {
+		return err
+	}
+	link := ""
+	if fi.Mode()&os.ModeSymlink != 0 {
+		if link, err = os.Readlink(p); err != nil {
+			return err
+		}
+	} else if !fi.Mode().IsRegular() {
+		return nil
+	}
+	hdr, err := tar.FileInfoHeader(fi, link)
+	if err != nil {
+		return err
+	}
+	hdr.Name = filepath.ToSlash(rel)
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	if link != "" {
+		return nil
+	}
+	src, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	_, err = io.Copy(tw, src)
+	return err
+}
+
+func extractTarball(src, root string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		rel := filepath.FromSlash(hdr.Name)
+		if !filepath.IsLocal(rel) {
+			return fmt.Errorf("unsafe path in archive: %s", hdr.Name)
+		}
+		p := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			return err
+		}
+		switch hdr.Typeflag {
+		case tar.TypeSymlink:
+			if err := os.Symlink(hdr.Linkname, p); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			dst, err := os.OpenFile(p, os.O_CREATE|os.O_EXCL|os.O_WRONLY, hdr.FileInfo().Mode().Perm())
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(dst, tr)
+			if cerr := dst.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
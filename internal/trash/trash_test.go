@@ -0,0 +1,164 @@
+package trash
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func gitIn(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v failed: %v: %s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// setupTrashRepo creates a repository with a worktree on branch that has one commit,
+// a modified tracked file and an untracked file.
+func setupTrashRepo(t *testing.T, branch string) (repo, wt string) {
+	t.Helper()
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	root := t.TempDir()
+	repo = filepath.Join(root, "repo")
+	gitIn(t, root, "init", "--initial-branch=main", repo)
+	gitIn(t, repo, "config", "user.email", "test@example.com")
+	gitIn(t, repo, "config", "user.name", "Test User")
+	writeFile(t, filepath.Join(repo, "README.md"), "init")
+	gitIn(t, repo, "add", ".")
+	gitIn(t, repo, "commit", "-m", "init")
+
+	wt = filepath.Join(root, "wt")
+	gitIn(t, repo, "worktree", "add", wt, "-b", branch)
+	writeFile(t, filepath.Join(wt, "work.txt"), "committed")
+	gitIn(t, wt, "add", "work.txt")
+	gitIn(t, wt, "commit", "-m", "feat: work")
+	writeFile(t, filepath.Join(wt, "README.md"), "edited")
+	writeFile(t, filepath.Join(wt, "notes", "todo.txt"), "untracked")
+	return repo, wt
+}
+
+func writeFile(t *testing.T, p, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", p, err)
+	}
+}
+
+func readFile(t *testing.T, p string) string {
+	t.Helper()
+	data, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatalf("read %s: %v", p, err)
+	}
+	return string(data)
+}
+
+func TestRestore_shouldRecreateBranchWorktreeAndChanges_whenRemovedWithTrash(t *testing.T) {
+	const branch = "feature/trash"
+	repo, wt := setupTrashRepo(t, branch)
+	head := gitIn(t, wt, "rev-parse", "HEAD")
+
+	e, err := Record(repo, wt, branch, "main")
+	if err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	if !e.Patch || e.Untracked != 1 || e.Head != head {
+		t.Fatalf("unexpected entry: %+v", e)
+	}
+	gitIn(t, repo, "worktree", "remove", "--force", wt)
+	gitIn(t, repo, "branch", "-D", branch)
+	gitIn(t, repo, "reflog", "expire", "--expire=now", "--all")
+	gitIn(t, repo, "gc", "--prune=now", "--quiet")
+
+	found, err := Find(repo, branch)
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+	p, err := Restore(repo, found, "")
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if p != wt {
+		t.Fatalf("restored to %s, want %s", p, wt)
+	}
+	if got := gitIn(t, wt, "rev-parse", "HEAD"); got != head {
+		t.Fatalf("HEAD = %s, want %s", got, head)
+	}
+	if got := gitIn(t, wt, "branch", "--show-current"); got != branch {
+		t.Fatalf("branch = %s, want %s", got, branch)
+	}
+	if got := readFile(t, filepath.Join(wt, "README.md")); got != "edited" {
+		t.Fatalf("tracked change not restored: %q", got)
+	}
+	if got := readFile(t, filepath.Join(wt, "notes", "todo.txt")); got != "untracked" {
+		t.Fatalf("untracked file not restored: %q", got)
+	}
+
+	entries, err := List(repo)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("expected entry dropped after restore, got %d", len(entries))
+	}
+	if refs := gitIn(t, repo, "for-each-ref", refPrefix); refs != "" {
+		t.Fatalf("expected trash ref deleted, got %s", refs)
+	}
+}
+
+func TestRestore_shouldFail_whenPathExists(t *testing.T) {
+	const branch = "feature/occupied"
+	repo, wt := setupTrashRepo(t, branch)
+	e, err := Record(repo, wt, branch, "main")
+	if err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	if _, err := Restore(repo, e, ""); err == nil {
+		t.Fatal("expected restore over an existing path to fail")
+	}
+	if _, err := Find(repo, e.ID); err != nil {
+		t.Fatalf("expected entry kept: %v", err)
+	}
+}
+
+func TestPurge_shouldDropOnlyOlderEntries(t *testing.T) {
+	const branch = "feature/purge"
+	repo, wt := setupTrashRepo(t, branch)
+	old, err := Record(repo, wt, branch, "main")
+	if err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	old.RemovedAt = time.Now().Add(-40 * 24 * time.Hour)
+	if err := old.save(); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	recent, err := Record(repo, wt, branch, "main")
+	if err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+
+	purged, err := Purge(repo, time.Now().Add(-30*24*time.Hour))
+	if err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+	if len(purged) != 1 || purged[0].ID != old.ID {
+		t.Fatalf("unexpected purged entries: %+v", purged)
+	}
+	entries, err := List(repo)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(entries) != 1 || entries[0].ID != recent.ID {
+		t.Fatalf("unexpected remaining entries: %+v", entries)
+	}
+}
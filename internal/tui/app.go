@@ -1,9 +1,10 @@
 package tui
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"os"
-	"os/exec"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/help"
@@ -42,6 +43,9 @@ type WorktreeItem struct {
 	IsCurrent bool
 	Status    string
 	Assignees []string
+	// Locked is set for worktrees locked with gw lock; LockReason may be empty.
+	Locked     bool
+	LockReason string
 }
 
 type Model struct {
@@ -117,6 +121,9 @@ type worktreeCreatedMsg struct {
 
 type worktreeDeletedMsg struct {
 	err error
+	// keptBranch is the branch left in place after removing the worktree, and keptReason why.
+	keptBranch string
+	keptReason string
 }
 
 type symlinksLoadedMsg struct {
@@ -145,10 +152,12 @@ func loadWorktrees() tea.Msg {
 			branch = "(detached)"
 		}
 		items = append(items, WorktreeItem{
-			Path:      wt.Path,
-			Branch:    branch,
-			IsPrimary: samePath(wt.Path, primaryPath),
-			IsCurrent: samePath(wt.Path, currentPath),
+			Path:       wt.Path,
+			Branch:     branch,
+			IsPrimary:  fsutil.SamePath(wt.Path, primaryPath),
+			IsCurrent:  fsutil.SamePath(wt.Path, currentPath),
+			Locked:     wt.Locked,
+			LockReason: wt.LockReason,
 		})
 	}
 
@@ -162,10 +171,6 @@ func findPrimaryPath(wts []gitx.Worktree) string {
 	return wts[0].Path
 }
 
-func samePath(a, b string) bool {
-	return a != "" && b != "" && a == b
-}
-
 func (m Model) loadSymlinks() tea.Cmd {
 	return func() tea.Msg {
 		if m.currentPath == "" {
@@ -195,24 +200,26 @@ func (m Model) createWorktree(branchName string) tea.Cmd {
 			return worktreeCreatedMsg{err: err}
 		}
 
+		primaryPath, _ := gitx.Root("")
+		hc := hooks.Context{Event: hooks.PreCreate, Branch: branchName, Path: wtPath, BaseRef: primary}
+		if err := runPreHook(primaryPath, hc); err != nil {
+			return worktreeCreatedMsg{err: err}
+		}
+
 		_, err = gitx.Cmd("", "worktree", "add", "-b", branchName, wtPath, primary)
 		if err != nil {
 			return worktreeCreatedMsg{err: err}
 		}
 
-		primaryPath, _ := gitx.Root("")
 		_, symErr := worktree.CreateSymlinksFromGitignored(primaryPath, wtPath, worktree.SymlinkOptions{})
 		if symErr != nil {
 			fmt.Fprintf(os.Stderr, "Warning: symlink creation failed: %v\n", symErr)
 		}
 
-		env := map[string]string{
-			"GW_HOOK_NAME": "post-create",
-			"GW_BRANCH":    branchName,
-			"GW_PATH":      wtPath,
-		}
+		hc.Event = hooks.PostCreate
+		env := hc.Env(wtPath)
 		go func() {
-			_, _ = hooks.RunHook(wtPath, "post-create", env, hooks.Options{Background: true})
+			_, _ = hooks.RunHook(wtPath, hooks.PostCreate, env, hooks.Options{Background: true, Branch: branchName, Output: io.Discard})
 		}()
 
 		return worktreeCreatedMsg{path: wtPath}
@@ -221,17 +228,59 @@ func (m Model) createWorktree(branchName string) tea.Cmd {
 
 func (m Model) deleteWorktree(wtPath, branch string) tea.Cmd {
 	return func() tea.Msg {
+		// The TUI has no --force-protected; protected worktrees are removed with gw rm.
+		if reason := worktree.LoadProtection(m.repoRoot).Reason(branch); reason != "" {
+			return worktreeDeletedMsg{err: fmt.Errorf("%s is protected (%s); use gw rm --force-protected", branch, reason)}
+		}
+		hc := hooks.Context{Event: hooks.PreRemove, Branch: branch, Path: wtPath}
+		if err := runPreHook(wtPath, hc); err != nil {
+			return worktreeDeletedMsg{err: err}
+		}
+
 		_, err := gitx.Cmd("", "worktree", "remove", wtPath)
 		if err != nil {
 			return worktreeDeletedMsg{err: err}
 		}
 
-		exec.Command("git", "branch", "-d", branch).Run()
+		hc.Event = hooks.PostRemove
+		env := hc.Env(m.repoRoot)
+		_, _ = hooks.RunHook(m.repoRoot, hooks.PostRemove, env, hooks.Options{Background: true, Branch: branch, Output: io.Discard})
+
+		if branch == "" || branch == "HEAD" {
+			return worktreeDeletedMsg{}
+		}
+		// Like gw rm, never drop commits that exist nowhere else; the TUI cannot prompt.
+		commits, err := gitx.UnsafeCommits(m.repoRoot, branch)
+		if err != nil {
+			return worktreeDeletedMsg{keptBranch: branch, keptReason: err.Error()}
+		}
+		if len(commits) > 0 {
+			return worktreeDeletedMsg{keptBranch: branch, keptReason: fmt.Sprintf("%d unpushed, unmerged commit(s)", len(commits))}
+		}
+		hc.Event = hooks.PreRemoveBranch
+		if err := runPreHook(m.repoRoot, hc); err != nil {
+			return worktreeDeletedMsg{keptBranch: branch, keptReason: err.Error()}
+		}
+		_, _ = gitx.Cmd(m.repoRoot, "branch", "-D", branch)
 
 		return worktreeDeletedMsg{}
 	}
 }
 
+// runPreHook runs a pre-* hook in dir, capturing its output so it does not draw over
+// the TUI. Failures that abort are returned with the captured output.
+func runPreHook(dir string, hc hooks.Context) error {
+	var output bytes.Buffer
+	_, err := hooks.RunHook(dir, hc.Event, hc.Env(dir), hooks.Options{Output: &output, Branch: hc.Branch})
+	if hooks.Aborts(err) {
+		if msg := strings.TrimSpace(output.String()); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
 func (m Model) createSymlink(s panel.SymlinkItem) tea.Cmd {
 	return func() tea.Msg {
 		if m.currentPath == "" || m.repoRoot == "" {
@@ -241,12 +290,35 @@ func (m Model) createSymlink(s panel.SymlinkItem) tea.Cmd {
 		src := s.Target
 		dst := m.currentPath + "/" + s.Path
 
-		if err := fsutil.CreateSymlink(src, dst); err != nil {
+		var res worktree.SymlinkResult
+		opts := worktree.SymlinkOptions{Relative: worktree.RelativeFromConfig(m.repoRoot)}
+		if err := worktree.Link(src, dst, opts, &res); err != nil {
 			return symlinkActionMsg{err: err}
 		}
+		if res.Created == 0 {
+			return symlinkActionMsg{action: fmt.Sprintf("Skipped %s: real file in the way", s.Path)}
+		}
 
-		return symlinkActionMsg{action: fmt.Sprintf("Created symlink: %s", s.Path)}
+		return symlinkActionMsg{action: fmt.Sprintf("Created symlink: %s%s", s.Path, conflictSummary(res))}
+	}
+}
+
+// conflictSummary describes real files that were backed up or skipped, for status messages.
+func conflictSummary(res worktree.SymlinkResult) string {
+	var parts []string
+	if res.Refreshed > 0 {
+		parts = append(parts, fmt.Sprintf("%d copies refreshed", res.Refreshed))
+	}
+	if n := len(res.BackedUp); n > 0 {
+		parts = append(parts, fmt.Sprintf("%d backed up as *.gw-bak.*", n))
 	}
+	if n := len(res.Skipped); n > 0 {
+		parts = append(parts, fmt.Sprintf("%d skipped", n))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
 }
 
 func (m Model) removeSymlink(s panel.SymlinkItem) tea.Cmd {
@@ -271,12 +343,12 @@ func (m Model) syncSymlinks() tea.Cmd {
 			return symlinkActionMsg{err: fmt.Errorf("not in a worktree")}
 		}
 
-		count, err := worktree.CreateSymlinksFromGitignored(m.repoRoot, m.currentPath, worktree.SymlinkOptions{})
+		res, err := worktree.CreateSymlinksFromGitignored(m.repoRoot, m.currentPath, worktree.SymlinkOptions{})
 		if err != nil {
 			return symlinkActionMsg{err: err}
 		}
 
-		return symlinkActionMsg{action: fmt.Sprintf("Synced %d symlinks", count)}
+		return symlinkActionMsg{action: fmt.Sprintf("Synced %d symlinks%s", res.Created, conflictSummary(res))}
 	}
 }
 
@@ -384,6 +456,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.message = "Cannot delete current worktree"
 					return m, nil
 				}
+				if wt.Locked {
+					m.message = "Cannot delete locked worktree; run gw unlock first"
+					return m, nil
+				}
 				m.modalType = DeleteConfirmModal
 				m.confirmModal = component.NewConfirmModal(
 					"Delete Worktree",
@@ -465,6 +541,9 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 		m.message = "Worktree deleted"
+		if msg.keptBranch != "" {
+			m.message = fmt.Sprintf("Worktree deleted; kept branch %s (%s)", msg.keptBranch, msg.keptReason)
+		}
 		return m, loadWorktrees
 
 	case statusUpdatedMsg:
@@ -733,6 +812,15 @@ func (m Model) renderWorktreeItem(idx int, wt WorktreeItem, maxBranchLen int) st
 		}
 	}
 
+	if wt.Locked {
+		lock := "⊘ locked"
+		if wt.LockReason != "" {
+			lock += ": " + wt.LockReason
+		}
+		b.WriteString("  ")
+		b.WriteString(lockedStyle.Render(lock))
+	}
+
 	if wt.IsCurrent {
 		b.WriteString("  ")
 		b.WriteString(currentWorktreeStyle.Render("← current"))
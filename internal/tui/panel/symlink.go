@@ -1,12 +1,9 @@
 package panel
 
 import (
-	"os"
-	"path/filepath"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
-	"github.com/sh0o0/gw/internal/gitx"
 	"github.com/sh0o0/gw/internal/worktree"
 )
 
@@ -42,92 +39,35 @@ type SymlinkItem struct {
 }
 
 func LoadSymlinks(wtPath string) ([]SymlinkItem, error) {
-	primaryPath, err := gitx.Root(wtPath)
+	primaryPath, err := worktree.PrimaryRoot(wtPath)
 	if err != nil {
 		return nil, err
 	}
 
-	files, err := worktree.GitIgnoredFiles(primaryPath)
+	statuses, err := worktree.CheckLinks(primaryPath, wtPath)
 	if err != nil {
 		return nil, err
 	}
 
-	pats := worktree.SymlinkPatterns(primaryPath)
-	excludes := worktree.ExcludePatterns(primaryPath)
-
 	var items []SymlinkItem
-	for _, f := range files {
-		if shouldExclude(f, excludes) {
-			continue
-		}
-		if !matchAnyPattern("/"+f, pats) {
-			continue
-		}
-
-		srcPath := filepath.Join(primaryPath, f)
-		dstPath := filepath.Join(wtPath, f)
-
+	for _, st := range statuses {
 		item := SymlinkItem{
-			Path:       f,
+			Path:       st.Path,
 			IsLinkable: true,
 		}
-
-		if info, err := os.Lstat(dstPath); err == nil {
-			if info.Mode()&os.ModeSymlink != 0 {
-				target, _ := os.Readlink(dstPath)
-				item.IsSymlink = true
-				item.Target = target
-			}
-		} else {
-			if _, err := os.Lstat(srcPath); err == nil {
-				item.Target = srcPath
-			}
+		switch {
+		case st.Link != "":
+			item.IsSymlink = true
+			item.Target = st.Link
+		case st.State == worktree.LinkMissing:
+			item.Target = st.Source
 		}
-
 		items = append(items, item)
 	}
 
 	return items, nil
 }
 
-func shouldExclude(path string, excludes []string) bool {
-	for _, p := range excludes {
-		if matched, _ := filepath.Match(p, path); matched {
-			return true
-		}
-		if strings.Contains(p, "**") {
-			if matchGlob(p, path) {
-				return true
-			}
-		}
-	}
-	return false
-}
-
-func matchAnyPattern(path string, patterns []string) bool {
-	for _, p := range patterns {
-		if matched, _ := filepath.Match(p, path); matched {
-			return true
-		}
-		if strings.Contains(p, "**") {
-			if matchGlob(p, path) {
-				return true
-			}
-		}
-	}
-	return false
-}
-
-func matchGlob(pattern, path string) bool {
-	parts := strings.Split(pattern, "**")
-	if len(parts) == 2 {
-		prefix := parts[0]
-		suffix := parts[1]
-		return strings.HasPrefix(path, prefix) && strings.HasSuffix(path, suffix)
-	}
-	return false
-}
-
 func RenderSymlinkItem(idx int, item SymlinkItem, selected bool, maxPathLen int) string {
 	var b strings.Builder
 
@@ -51,6 +51,9 @@ var (
 			Foreground(infoColor).
 			Italic(true)
 
+	lockedStyle = lipgloss.NewStyle().
+			Foreground(warningColor)
+
 	branchStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("#BD93F9"))
 
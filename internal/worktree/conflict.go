@@ -0,0 +1,105 @@
+package worktree
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sh0o0/gw/internal/gitx"
+)
+
+// ConflictPolicy decides what happens when a symlink destination is a real file or directory.
+type ConflictPolicy string
+
+const (
+	// ConflictSkip leaves the existing file alone.
+	ConflictSkip ConflictPolicy = "skip"
+	// ConflictBackup renames the existing file to <name>.gw-bak.<timestamp>. This is the default.
+	ConflictBackup ConflictPolicy = "backup"
+	// ConflictOverwrite deletes the existing file.
+	ConflictOverwrite ConflictPolicy = "overwrite"
+	// ConflictPrompt asks through SymlinkOptions.Prompt, falling back to backup.
+	ConflictPrompt ConflictPolicy = "prompt"
+)
+
+const configKeySymlinkConflict = "gw.symlink.conflict"
+
+func ParseConflictPolicy(v string) (ConflictPolicy, error) {
+	switch p := ConflictPolicy(strings.ToLower(strings.TrimSpace(v))); p {
+	case ConflictSkip, ConflictBackup, ConflictOverwrite, ConflictPrompt:
+		return p, nil
+	default:
+		return "", fmt.Errorf("invalid conflict policy: %s (want skip, backup, overwrite or prompt)", v)
+	}
+}
+
+// ConflictPolicyFromConfig reads gw.symlink.conflict, defaulting to ConflictBackup.
+func ConflictPolicyFromConfig(cwd string) ConflictPolicy {
+	if v, err := gitx.ConfigGet(cwd, configKeySymlinkConflict); err == nil {
+		if p, err := ParseConflictPolicy(v); err == nil {
+			return p
+		}
+	}
+	return ConflictBackup
+}
+
+// Backup records a real file that was moved out of the way of a symlink or copy.
+type Backup struct {
+	Path       string
+	BackupPath string
+}
+
+// SymlinkResult summarises what CreateSymlinksFromGitignored did.
+type SymlinkResult struct {
+	// Created counts symlinks and copies placed.
+	Created int
+	// Refreshed counts copies replaced because their source changed.
+	Refreshed int
+	// Skipped lists destinations left untouched because a real file was in the way.
+	Skipped []string
+	// BackedUp lists real files renamed to make room for a symlink.
+	BackedUp []Backup
+}
+
+// resolveConflict makes room for a link at dst. It returns false when dst must be left
+// alone. Existing symlinks are never a conflict: they are replaced.
+func resolveConflict(dst string, opts SymlinkOptions, res *SymlinkResult) (bool, error) {
+	fi, err := os.Lstat(dst)
+	if err != nil || fi.Mode()&os.ModeSymlink != 0 {
+		return true, nil
+	}
+	policy := opts.Conflict
+	if policy == ConflictPrompt {
+		policy = ConflictBackup
+		if opts.Prompt != nil {
+			policy = opts.Prompt(dst)
+		}
+	}
+	switch policy {
+	case ConflictSkip:
+		res.Skipped = append(res.Skipped, dst)
+		return false, nil
+	case ConflictOverwrite:
+		return true, os.RemoveAll(dst)
+	default:
+		backup := backupPath(dst, time.Now())
+		if err := os.Rename(dst, backup); err != nil {
+			return false, err
+		}
+		res.BackedUp = append(res.BackedUp, Backup{Path: dst, BackupPath: backup})
+		return true, nil
+	}
+}
+
+// backupPath returns an unused <dst>.gw-bak.<timestamp> path.
+func backupPath(dst string, now time.Time) string {
+	base := dst + ".gw-bak." + now.Format("20060102-150405")
+	p := base
+	for i := 1; ; i++ {
+		if _, err := os.Lstat(p); os.IsNotExist(err) {
+			return p
+		}
+		p = fmt.Sprintf("%s-%d", base, i)
+	}
+}
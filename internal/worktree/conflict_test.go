@@ -0,0 +1,104 @@
+package worktree
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func setupConflict(t *testing.T) (src, dst string) {
+	t.Helper()
+	dir := t.TempDir()
+	src = filepath.Join(dir, "primary", ".env")
+	dst = filepath.Join(dir, "wt", ".env")
+	for p, content := range map[string]string{src: "shared", dst: "custom"} {
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return src, dst
+}
+
+func TestLink_shouldBackUpRealFile_whenPolicyBackup(t *testing.T) {
+	src, dst := setupConflict(t)
+	var res SymlinkResult
+	if err := Link(src, dst, SymlinkOptions{Conflict: ConflictBackup}, &res); err != nil {
+		t.Fatalf("Link: %v", err)
+	}
+	if res.Created != 1 || len(res.BackedUp) != 1 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	b := res.BackedUp[0]
+	if !strings.HasPrefix(filepath.Base(b.BackupPath), ".env.gw-bak.") {
+		t.Fatalf("unexpected backup name: %s", b.BackupPath)
+	}
+	if data, _ := os.ReadFile(b.BackupPath); string(data) != "custom" {
+		t.Fatalf("backup lost content: %q", data)
+	}
+	if fi, err := os.Lstat(dst); err != nil || fi.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("expected symlink at %s", dst)
+	}
+}
+
+func TestLink_shouldLeaveRealFile_whenPolicySkip(t *testing.T) {
+	src, dst := setupConflict(t)
+	var res SymlinkResult
+	if err := Link(src, dst, SymlinkOptions{Conflict: ConflictSkip}, &res); err != nil {
+		t.Fatalf("Link: %v", err)
+	}
+	if res.Created != 0 || len(res.Skipped) != 1 || res.Skipped[0] != dst {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	if data, _ := os.ReadFile(dst); string(data) != "custom" {
+		t.Fatalf("file was modified: %q", data)
+	}
+}
+
+func TestLink_shouldReplaceRealFile_whenPolicyOverwrite(t *testing.T) {
+	src, dst := setupConflict(t)
+	var res SymlinkResult
+	if err := Link(src, dst, SymlinkOptions{Conflict: ConflictOverwrite}, &res); err != nil {
+		t.Fatalf("Link: %v", err)
+	}
+	if data, _ := os.ReadFile(dst); string(data) != "shared" {
+		t.Fatalf("expected link to source, got %q", data)
+	}
+	if matches, _ := filepath.Glob(dst + ".gw-bak.*"); len(matches) != 0 {
+		t.Fatalf("unexpected backup: %v", matches)
+	}
+}
+
+func TestLink_shouldAskPrompt_whenPolicyPrompt(t *testing.T) {
+	src, dst := setupConflict(t)
+	var asked string
+	opts := SymlinkOptions{Conflict: ConflictPrompt, Prompt: func(p string) ConflictPolicy {
+		asked = p
+		return ConflictSkip
+	}}
+	var res SymlinkResult
+	if err := Link(src, dst, opts, &res); err != nil {
+		t.Fatalf("Link: %v", err)
+	}
+	if asked != dst || len(res.Skipped) != 1 {
+		t.Fatalf("expected prompt for %s, got %q %+v", dst, asked, res)
+	}
+}
+
+func TestLink_shouldReplaceExistingSymlink_withoutConflict(t *testing.T) {
+	src, dst := setupConflict(t)
+	os.Remove(dst)
+	if err := os.Symlink("/nonexistent", dst); err != nil {
+		t.Fatal(err)
+	}
+	var res SymlinkResult
+	if err := Link(src, dst, SymlinkOptions{Conflict: ConflictSkip}, &res); err != nil {
+		t.Fatalf("Link: %v", err)
+	}
+	if res.Created != 1 || len(res.Skipped) != 0 || len(res.BackedUp) != 0 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+}
@@ -0,0 +1,223 @@
+package worktree
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/sh0o0/gw/internal/fsutil"
+	"github.com/sh0o0/gw/internal/gitx"
+)
+
+// copyManifest records the files a worktree received as copies (or hard links and
+// reflinks) and the content hash they were placed with, so a later sync can tell an
+// untouched copy of an outdated source from a file the user edited.
+// It lives in the worktree's git dir as gw/copies.json.
+type copyManifest struct {
+	path    string
+	dirty   bool
+	Entries map[string]copyEntry `json:"entries"`
+}
+
+type copyEntry struct {
+	Mode Mode   `json:"mode"`
+	Hash string `json:"hash"`
+}
+
+func loadCopyManifest(target string) *copyManifest {
+	m := &copyManifest{Entries: map[string]copyEntry{}}
+	gitDir, err := gitx.GitDir(target)
+	if err != nil {
+		return m
+	}
+	m.path = filepath.Join(gitDir, "gw", "copies.json")
+	if data, err := os.ReadFile(m.path); err == nil {
+		_ = json.Unmarshal(data, m)
+		if m.Entries == nil {
+			m.Entries = map[string]copyEntry{}
+		}
+	}
+	return m
+}
+
+func (m *copyManifest) set(rel string, e copyEntry) {
+	if m.Entries[rel] != e {
+		m.Entries[rel] = e
+		m.dirty = true
+	}
+}
+
+func (m *copyManifest) save() error {
+	if !m.dirty || m.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(m.path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(m.path, data, 0o644)
+}
+
+// place puts a copy, hard link or reflink of src at dst (rel is dst relative to the
+// worktree root). An up-to-date dst is left alone; a dst still holding what gw placed
+// there is refreshed when src changed; anything else is a conflict handled by
+// opts.Conflict.
+func place(src, dst, rel string, mode Mode, m *copyManifest, opts SymlinkOptions, res *SymlinkResult) error {
+	if actual, err := filepath.EvalSymlinks(src); err == nil {
+		src = actual
+	}
+	srcHash, err := hashPath(src)
+	if err != nil {
+		return err
+	}
+	entry := copyEntry{Mode: mode, Hash: srcHash}
+	refresh := false
+	if fi, err := os.Lstat(dst); err == nil && fi.Mode()&os.ModeSymlink == 0 {
+		if mode == ModeHardlink && sameFile(src, dst) {
+			m.set(rel, entry)
+			return nil
+		}
+		dstHash, err := hashPath(dst)
+		if err != nil {
+			return err
+		}
+		prev, tracked := m.Entries[rel]
+		switch {
+		case dstHash == srcHash && (!tracked || prev.Mode == mode):
+			m.set(rel, entry)
+			return nil
+		case tracked && (dstHash == prev.Hash || dstHash == srcHash):
+			// Untouched since gw placed it: the source or the mode changed.
+			refresh = true
+			if err := os.RemoveAll(dst); err != nil {
+				return err
+			}
+		default:
+			ok, err := resolveConflict(dst, opts, res)
+			if err != nil || !ok {
+				return err
+			}
+		}
+	} else if err == nil {
+		if err := os.Remove(dst); err != nil {
+			return err
+		}
+	}
+
+	if err := placeByMode(src, dst, mode); err != nil {
+		os.RemoveAll(dst)
+		return err
+	}
+	m.set(rel, entry)
+	if refresh {
+		res.Refreshed++
+	} else {
+		res.Created++
+	}
+	if opts.Verbose {
+		verb := "Placed"
+		if refresh {
+			verb = "Refreshed"
+		}
+		fmt.Fprintf(os.Stderr, "%s %s: %s <- %s\n", verb, mode, dst, src)
+	}
+	return nil
+}
+
+func placeByMode(src, dst string, mode Mode) error {
+	fi, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if err := fsutil.EnsureDir(filepath.Dir(dst)); err != nil {
+		return err
+	}
+	copyFile := fsutil.CopyFile
+	switch mode {
+	case ModeHardlink:
+		copyFile = hardlinkFile
+	case ModeReflink:
+		copyFile = reflinkFile
+	}
+	if fi.IsDir() {
+		return fsutil.CopyDirFunc(src, dst, copyFile)
+	}
+	return copyFile(src, dst)
+}
+
+func hardlinkFile(src, dst string) error {
+	if err := fsutil.EnsureDir(filepath.Dir(dst)); err != nil {
+		return err
+	}
+	if err := os.Link(src, dst); err != nil {
+		// Typically EXDEV: the worktree is on another filesystem.
+		return fsutil.CopyFile(src, dst)
+	}
+	return nil
+}
+
+func reflinkFile(src, dst string) error {
+	if err := fsutil.EnsureDir(filepath.Dir(dst)); err != nil {
+		return err
+	}
+	if err := cloneFile(src, dst); err != nil {
+		os.Remove(dst)
+		return fsutil.CopyFile(src, dst)
+	}
+	return nil
+}
+
+func sameFile(a, b string) bool {
+	fa, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	fb, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(fa, fb)
+}
+
+// hashPath hashes the content of a file, or the relative paths, symlink targets and
+// file contents of a directory tree.
+func hashPath(p string) (string, error) {
+	h := sha256.New()
+	err := filepath.Walk(p, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(p, path)
+		switch {
+		case info.IsDir():
+			fmt.Fprintf(h, "dir\x00%s\x00", rel)
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(h, "link\x00%s\x00%s\x00", rel, link)
+		case info.Mode().IsRegular():
+			fmt.Fprintf(h, "file\x00%s\x00", rel)
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(h, f)
+			f.Close()
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,280 @@
+package worktree
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sh0o0/gw/internal/gitx"
+)
+
+// GitIgnoredFiles lists the gitignored files of root that gw.symlink.include may
+// match. Ignored directories are listed by git as a whole and only expanded when an
+// include pattern could match inside them and gw.symlink.exclude does not cover them,
+// so trees like node_modules are never walked. Results are cached in the worktree's
+// git dir until the index, a relevant directory or an ignore file changes.
+func GitIgnoredFiles(root string) ([]string, error) {
+	d := discovery{root: root, includes: SymlinkPatterns(root), excludes: ExcludePatterns(root)}
+	cachePath, key := d.cacheKey()
+	if files, ok := loadIgnoredCache(root, cachePath, key); ok {
+		return files, nil
+	}
+	files, err := d.run()
+	if err != nil {
+		return nil, err
+	}
+	if cachePath != "" && d.cacheable {
+		saveIgnoredCache(cachePath, ignoredCache{Key: key, Dirs: d.dirs, Files: files})
+	}
+	return files, nil
+}
+
+// mtimeSlack covers file systems with coarse modification times.
+const mtimeSlack = 2 * time.Second
+
+// discovery lists ignored files and records the directories the result depends on.
+type discovery struct {
+	root     string
+	includes []string
+	excludes []string
+	start    time.Time
+	// dirs maps every directory whose listing affects the result to its stamp.
+	dirs map[string]stamp
+	// cacheable is false when the result may already be out of date.
+	cacheable bool
+}
+
+func (d *discovery) run() ([]string, error) {
+	d.start = time.Now()
+	d.dirs = map[string]stamp{}
+	d.cacheable = true
+	// --untracked-files=normal overrides status.showUntrackedFiles: "no" is rejected
+	// together with --ignored and "all" would not report untracked dirs as "dir/".
+	out, err := gitx.Cmd(d.root, "--no-optional-locks", "status", "--porcelain", "-z",
+		"--ignored=matching", "--untracked-files=normal", "--no-renames", "--ignore-submodules=all")
+	if err != nil {
+		return nil, err
+	}
+	var files, untracked []string
+	ignoredDirs := map[string]bool{}
+	for _, entry := range strings.Split(out, "\x00") {
+		if len(entry) < 4 {
+			continue
+		}
+		code, p := entry[:2], entry[3:]
+		switch {
+		case code == "!!" && strings.HasSuffix(p, "/"):
+			dir := strings.TrimSuffix(p, "/")
+			ignoredDirs[dir] = true
+			if !d.prune(dir) {
+				files = d.expand(dir, files)
+			}
+		case code == "!!":
+			files = append(files, p)
+			d.record(path.Dir(p))
+		case code == "??" && strings.HasSuffix(p, "/"):
+			untracked = append(untracked, strings.TrimSuffix(p, "/"))
+		}
+	}
+
+	// New files in tracked or untracked directories change those directories' mtimes.
+	d.record(".")
+	if tree, err := gitx.Cmd(d.root, "ls-tree", "-r", "-d", "--name-only", "-z", "HEAD"); err == nil {
+		for _, dir := range strings.Split(tree, "\x00") {
+			if dir != "" {
+				d.record(dir)
+			}
+		}
+	} else {
+		d.cacheable = false
+	}
+	for _, dir := range untracked {
+		d.walk(dir, func(rel string, isDir bool) bool {
+			if !isDir || ignoredDirs[rel] {
+				return false
+			}
+			d.record(rel)
+			// git does not look inside nested repositories and worktrees.
+			_, err := os.Lstat(filepath.Join(d.root, rel, ".git"))
+			return err != nil
+		})
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// expand appends the files under the ignored directory dir, pruning subdirectories.
+func (d *discovery) expand(dir string, files []string) []string {
+	d.walk(dir, func(rel string, isDir bool) bool {
+		if !isDir {
+			files = append(files, rel)
+			return false
+		}
+		if rel != dir && d.prune(rel) {
+			return false
+		}
+		d.record(rel)
+		return true
+	})
+	return files
+}
+
+// walk calls visit for every entry under dir (relative to root), descending into
+// directories for which visit returns true. Symlinks are not followed.
+func (d *discovery) walk(dir string, visit func(rel string, isDir bool) bool) {
+	_ = filepath.WalkDir(filepath.Join(d.root, dir), func(p string, e os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(d.root, p)
+		rel = filepath.ToSlash(rel)
+		if !visit(rel, e.IsDir()) && e.IsDir() {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+}
+
+// prune reports whether nothing under dir can be linked: an exclude pattern covers
+// everything in it, or no include pattern can match below it.
+func (d *discovery) prune(dir string) bool {
+	if shouldExclude(dir+"/\x00/\x00", d.excludes) {
+		return true
+	}
+	for _, p := range d.includes {
+		if couldMatchBelow(p, dir) {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *discovery) record(dir string) {
+	st := stampOf(filepath.Join(d.root, dir))
+	// A directory changed while we listed it, or so recently that a coarse mtime could
+	// hide the next change, must not be trusted later.
+	if recent := d.start.Add(-mtimeSlack).UnixNano(); st.Dir >= recent || st.Ignore >= recent {
+		d.cacheable = false
+	}
+	d.dirs[dir] = st
+}
+
+// couldMatchBelow reports whether pattern, matched against "/"+path as
+// CreateSymlinksFromGitignored does, may match a path inside dir. It errs on the side
+// of true.
+func couldMatchBelow(pattern, dir string) bool {
+	if strings.ContainsAny(pattern, "{\\") {
+		return true
+	}
+	pat := strings.Split(pattern, "/")
+	segs := strings.Split("/"+dir, "/")
+	for _, seg := range segs {
+		if len(pat) == 0 {
+			return false
+		}
+		if strings.Contains(pat[0], "**") {
+			return true
+		}
+		if len(pat) == 1 {
+			// The last segment names a file directly in this directory or above.
+			return false
+		}
+		if ok, err := path.Match(pat[0], seg); err != nil || !ok {
+			return err != nil
+		}
+		pat = pat[1:]
+	}
+	return len(pat) > 0
+}
+
+// stamp is the modification time of a directory and of its .gitignore (0 if absent).
+type stamp struct {
+	Dir    int64 `json:"d"`
+	Ignore int64 `json:"i,omitempty"`
+}
+
+func stampOf(dir string) stamp {
+	var st stamp
+	if fi, err := os.Stat(dir); err == nil {
+		st.Dir = fi.ModTime().UnixNano()
+	}
+	if fi, err := os.Stat(filepath.Join(dir, ".gitignore")); err == nil {
+		st.Ignore = fi.ModTime().UnixNano()
+	}
+	return st
+}
+
+type ignoredCache struct {
+	Key   string           `json:"key"`
+	Dirs  map[string]stamp `json:"dirs"`
+	Files []string         `json:"files"`
+}
+
+// cacheKey returns where the cache of root lives and the key it must carry: the
+// patterns, the index mtime and the mtimes of the repository-wide ignore files.
+func (d *discovery) cacheKey() (string, string) {
+	gitDir, err := gitx.GitDir(d.root)
+	if err != nil {
+		return "", ""
+	}
+	commonDir, err := gitx.CommonGitDir(d.root)
+	if err != nil {
+		return "", ""
+	}
+	var b strings.Builder
+	b.WriteString(strings.Join(d.includes, "\x00") + "\x01" + strings.Join(d.excludes, "\x00"))
+	for _, f := range []string{filepath.Join(gitDir, "index"), filepath.Join(commonDir, "info", "exclude"), globalExcludesFile(d.root)} {
+		var mtime int64
+		if fi, err := os.Stat(f); err == nil {
+			mtime = fi.ModTime().UnixNano()
+		}
+		b.WriteString("\x01" + f + "=" + time.Unix(0, mtime).UTC().Format(time.RFC3339Nano))
+	}
+	return filepath.Join(gitDir, "gw", "ignored-files.json"), b.String()
+}
+
+func globalExcludesFile(root string) string {
+	if v, err := gitx.ConfigGet(root, "core.excludesFile"); err == nil && v != "" {
+		if strings.HasPrefix(v, "~/") {
+			v = filepath.Join(os.Getenv("HOME"), v[2:])
+		}
+		return v
+	}
+	if x := os.Getenv("XDG_CONFIG_HOME"); x != "" {
+		return filepath.Join(x, "git", "ignore")
+	}
+	return filepath.Join(os.Getenv("HOME"), ".config", "git", "ignore")
+}
+
+func loadIgnoredCache(root, cachePath, key string) ([]string, bool) {
+	if cachePath == "" {
+		return nil, false
+	}
+	data, err := os.ReadFile(cachePath)
+	if err != nil {
+		return nil, false
+	}
+	var c ignoredCache
+	if json.Unmarshal(data, &c) != nil || c.Key != key {
+		return nil, false
+	}
+	for dir, st := range c.Dirs {
+		if stampOf(filepath.Join(root, dir)) != st {
+			return nil, false
+		}
+	}
+	return c.Files, true
+}
+
+func saveIgnoredCache(cachePath string, c ignoredCache) {
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0o755); err != nil {
+		return
+	}
+	if data, err := json.Marshal(c); err == nil {
+		_ = os.WriteFile(cachePath, data, 0o644)
+	}
+}
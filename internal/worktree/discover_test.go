@@ -0,0 +1,146 @@
+package worktree
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setupIgnoredRepo creates a repository with ignored files and dirs and ages every
+// directory so discovery results can be cached.
+func setupIgnoredRepo(t *testing.T) string {
+	t.Helper()
+	dir, _ := filepath.EvalSymlinks(t.TempDir())
+	gitIn(t, dir, "init", "-q")
+	gitIn(t, dir, "config", "--add", "gw.symlink.include", "**/.env*")
+	gitIn(t, dir, "config", "--add", "gw.symlink.exclude", "**/node_modules/**")
+	files := map[string]string{
+		".gitignore":               "node_modules/\nbuild/\n.env*\n",
+		"src/main.go":              "package main",
+		".env":                     "A=1",
+		"src/.env.local":           "B=2",
+		"build/.env":               "C=3",
+		"node_modules/pkg/.env":    "D=4",
+		"node_modules/pkg/a.js":    "",
+		"scratch/notes.txt":        "",
+		"scratch/sub/.env.scratch": "E=5",
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gitIn(t, dir, "add", ".gitignore", "src/main.go")
+	gitIn(t, dir, "commit", "-qm", "init")
+	age(t, dir)
+	return dir
+}
+
+func age(t *testing.T, root string) {
+	t.Helper()
+	old := time.Now().Add(-time.Hour)
+	_ = filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err == nil {
+			_ = os.Chtimes(p, old, old)
+		}
+		return nil
+	})
+}
+
+func TestGitIgnoredFiles_shouldExpandIgnoredDirs_andPruneExcluded(t *testing.T) {
+	root := setupIgnoredRepo(t)
+
+	files, err := GitIgnoredFiles(root)
+	if err != nil {
+		t.Fatalf("GitIgnoredFiles: %v", err)
+	}
+	got := strings.Join(files, ",")
+	want := ".env,build/.env,scratch/sub/.env.scratch,src/.env.local"
+	if got != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+}
+
+func TestGitIgnoredFiles_shouldInvalidateCache_whenFilesAppear(t *testing.T) {
+	root := setupIgnoredRepo(t)
+	if _, err := GitIgnoredFiles(root); err != nil {
+		t.Fatalf("GitIgnoredFiles: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, ".git", "gw", "ignored-files.json")); err != nil {
+		t.Fatalf("expected results to be cached: %v", err)
+	}
+
+	for _, p := range []string{"src/.env.test", "build/nested/.env"} {
+		full := filepath.Join(root, p)
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		files, err := GitIgnoredFiles(root)
+		if err != nil {
+			t.Fatalf("GitIgnoredFiles: %v", err)
+		}
+		if !strings.Contains(strings.Join(files, ","), p) {
+			t.Fatalf("expected new file %s to be found, got %v", p, files)
+		}
+		age(t, root)
+	}
+}
+
+func TestGitIgnoredFiles_shouldIgnoreShowUntrackedFiles(t *testing.T) {
+	for _, mode := range []string{"no", "all"} {
+		t.Run(mode, func(t *testing.T) {
+			root := setupIgnoredRepo(t)
+			gitIn(t, root, "config", "status.showUntrackedFiles", mode)
+
+			files, err := GitIgnoredFiles(root)
+			if err != nil {
+				t.Fatalf("GitIgnoredFiles: %v", err)
+			}
+			want := ".env,build/.env,scratch/sub/.env.scratch,src/.env.local"
+			if got := strings.Join(files, ","); got != want {
+				t.Fatalf("expected %s, got %s", want, got)
+			}
+
+			// The untracked scratch/ must still be watched for the cache to notice this.
+			full := filepath.Join(root, "scratch", "sub", ".env.new")
+			if err := os.WriteFile(full, nil, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			files, err = GitIgnoredFiles(root)
+			if err != nil {
+				t.Fatalf("GitIgnoredFiles: %v", err)
+			}
+			if !strings.Contains(strings.Join(files, ","), "scratch/sub/.env.new") {
+				t.Fatalf("expected new file to be found, got %v", files)
+			}
+		})
+	}
+}
+
+func TestCouldMatchBelow_shouldPruneAnchoredPatterns(t *testing.T) {
+	cases := []struct {
+		pattern, dir string
+		want         bool
+	}{
+		{"**/.env*", "node_modules", true},
+		{"/.vscode/*", ".vscode", true},
+		{"/.vscode/*", "target", false},
+		{"/.vscode/*", ".vscode/sub", false},
+		{"/config/*/local.yml", "config/dev", true},
+		{"/.env", "anything", false},
+	}
+	for _, c := range cases {
+		if got := couldMatchBelow(c.pattern, c.dir); got != c.want {
+			t.Errorf("couldMatchBelow(%q, %q) = %v, want %v", c.pattern, c.dir, got, c.want)
+		}
+	}
+}
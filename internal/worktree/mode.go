@@ -0,0 +1,85 @@
+package worktree
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/sh0o0/gw/internal/gitx"
+)
+
+// Mode decides how a matched gitignored file is placed in a worktree.
+type Mode string
+
+const (
+	// ModeSymlink links to the file in the source worktree. This is the default.
+	ModeSymlink Mode = "symlink"
+	// ModeCopy copies the file; gw sync refreshes it when the source changes.
+	ModeCopy Mode = "copy"
+	// ModeHardlink hard-links the file, falling back to a copy across filesystems.
+	ModeHardlink Mode = "hardlink"
+	// ModeReflink clones the file copy-on-write where the filesystem supports it
+	// (btrfs, XFS), falling back to a copy.
+	ModeReflink Mode = "reflink"
+)
+
+const configKeySymlinkMode = "gw.symlink.mode"
+
+func ParseMode(v string) (Mode, error) {
+	switch m := Mode(strings.ToLower(strings.TrimSpace(v))); m {
+	case ModeSymlink, ModeCopy, ModeHardlink, ModeReflink:
+		return m, nil
+	default:
+		return "", fmt.Errorf("invalid symlink mode: %s (want symlink, copy, hardlink or reflink)", v)
+	}
+}
+
+// ModeFromConfig reads gw.symlink.mode, defaulting to ModeSymlink.
+func ModeFromConfig(cwd string) Mode {
+	if v, err := gitx.ConfigGet(cwd, configKeySymlinkMode); err == nil {
+		if m, err := ParseMode(v); err == nil {
+			return m
+		}
+	}
+	return ModeSymlink
+}
+
+// Pattern is a gw.symlink.include entry. An entry may start with a mode, as in
+// "copy:**/.env*"; Mode is empty otherwise.
+type Pattern struct {
+	Glob string
+	Mode Mode
+}
+
+func parsePattern(s string) Pattern {
+	if i := strings.IndexByte(s, ':'); i > 0 {
+		if m, err := ParseMode(s[:i]); err == nil {
+			return Pattern{Glob: s[i+1:], Mode: m}
+		}
+	}
+	return Pattern{Glob: s}
+}
+
+// IncludePatterns returns the gw.symlink.include entries with their modes.
+func IncludePatterns(cwd string) []Pattern {
+	raw, _ := gitx.ConfigGetAll(cwd, "gw.symlink.include")
+	pats := make([]Pattern, 0, len(raw))
+	for _, s := range raw {
+		pats = append(pats, parsePattern(s))
+	}
+	return pats
+}
+
+// matchPattern returns the mode of the first pattern matching path, or def when that
+// pattern has no mode. ok is false when nothing matches.
+func matchPattern(path string, patterns []Pattern, def Mode) (mode Mode, ok bool) {
+	for _, p := range patterns {
+		if m, _ := doublestar.PathMatch(p.Glob, path); m {
+			if p.Mode != "" {
+				return p.Mode, true
+			}
+			return def, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,117 @@
+package worktree
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatchPattern_shouldUsePrefixMode_elseDefault(t *testing.T) {
+	pats := []Pattern{parsePattern("copy:**/.env*"), parsePattern("**/.vscode/*"), parsePattern("weird:name")}
+	if m, ok := matchPattern("/app/.env.local", pats, ModeSymlink); !ok || m != ModeCopy {
+		t.Fatalf("expected copy for .env, got %q ok=%v", m, ok)
+	}
+	if m, ok := matchPattern("/.vscode/settings.json", pats, ModeReflink); !ok || m != ModeReflink {
+		t.Fatalf("expected default mode for .vscode, got %q ok=%v", m, ok)
+	}
+	if pats[2].Glob != "weird:name" || pats[2].Mode != "" {
+		t.Fatalf("unknown prefixes must stay part of the glob: %+v", pats[2])
+	}
+	if _, ok := matchPattern("/README.md", pats, ModeSymlink); ok {
+		t.Fatalf("expected no match")
+	}
+}
+
+func setupPlace(t *testing.T) (src, dst string, m *copyManifest) {
+	t.Helper()
+	dir := t.TempDir()
+	src = filepath.Join(dir, "primary", ".env")
+	dst = filepath.Join(dir, "wt", ".env")
+	if err := os.MkdirAll(filepath.Dir(src), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(src, []byte("v1"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return src, dst, &copyManifest{Entries: map[string]copyEntry{}}
+}
+
+func TestPlace_shouldRefreshCopy_whenSourceChanged(t *testing.T) {
+	src, dst, m := setupPlace(t)
+	opts := SymlinkOptions{Conflict: ConflictBackup}
+	var res SymlinkResult
+	if err := place(src, dst, ".env", ModeCopy, m, opts, &res); err != nil {
+		t.Fatalf("place: %v", err)
+	}
+	if fi, err := os.Lstat(dst); err != nil || !fi.Mode().IsRegular() || fi.Mode().Perm() != 0o600 {
+		t.Fatalf("expected regular file with source permissions, got %v err=%v", fi, err)
+	}
+
+	if err := os.WriteFile(src, []byte("v2"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := place(src, dst, ".env", ModeCopy, m, opts, &res); err != nil {
+		t.Fatalf("place: %v", err)
+	}
+	if data, _ := os.ReadFile(dst); string(data) != "v2" {
+		t.Fatalf("expected refreshed copy, got %q", data)
+	}
+	if res.Created != 1 || res.Refreshed != 1 || len(res.BackedUp) != 0 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+}
+
+func TestPlace_shouldBackUpEditedCopy_whenSourceChanged(t *testing.T) {
+	src, dst, m := setupPlace(t)
+	opts := SymlinkOptions{Conflict: ConflictBackup}
+	var res SymlinkResult
+	if err := place(src, dst, ".env", ModeCopy, m, opts, &res); err != nil {
+		t.Fatalf("place: %v", err)
+	}
+	if err := os.WriteFile(dst, []byte("local edit"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(src, []byte("v2"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := place(src, dst, ".env", ModeCopy, m, opts, &res); err != nil {
+		t.Fatalf("place: %v", err)
+	}
+	if len(res.BackedUp) != 1 {
+		t.Fatalf("expected edited copy to be backed up: %+v", res)
+	}
+	if data, _ := os.ReadFile(res.BackedUp[0].BackupPath); string(data) != "local edit" {
+		t.Fatalf("backup lost content: %q", data)
+	}
+}
+
+func TestPlace_shouldShareInode_whenHardlink(t *testing.T) {
+	src, dst, m := setupPlace(t)
+	var res SymlinkResult
+	if err := place(src, dst, ".env", ModeHardlink, m, SymlinkOptions{Conflict: ConflictBackup}, &res); err != nil {
+		t.Fatalf("place: %v", err)
+	}
+	if !sameFile(src, dst) {
+		t.Fatalf("expected a hard link")
+	}
+}
+
+func TestPlace_shouldCopyDirectory_whenReflink(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "primary", ".vscode")
+	dst := filepath.Join(dir, "wt", ".vscode")
+	if err := os.MkdirAll(src, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "settings.json"), []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var res SymlinkResult
+	m := &copyManifest{Entries: map[string]copyEntry{}}
+	if err := place(src, dst, ".vscode", ModeReflink, m, SymlinkOptions{Conflict: ConflictBackup}, &res); err != nil {
+		t.Fatalf("place: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dst, "settings.json")); err != nil || string(data) != "{}" {
+		t.Fatalf("expected cloned file, got %q err=%v", data, err)
+	}
+}
@@ -0,0 +1,149 @@
+package worktree
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sh0o0/gw/internal/fsutil"
+	"github.com/sh0o0/gw/internal/gitx"
+)
+
+// ErrBaseInWorktree reports a worktree base inside a registered worktree, e.g.
+// gw.worktree.base pointing at the directory that holds the primary checkout. Its
+// entries are that worktree's own files, so they are never scanned for orphans.
+var ErrBaseInWorktree = errors.New("worktree base is inside a registered worktree")
+
+// FindOrphans returns the directories in the worktree base of the repository containing
+// cwd that are not registered worktrees, typically left behind when a removal failed
+// half way or a worktree's admin files were pruned. Directories that are repositories
+// of their own or worktrees of another repository, or that hold a .git anywhere
+// below them, are never returned. With gw.worktree.flat the base is shared by every
+// repository, so only directories whose .git file points back into this repository
+// are returned. Directories inside a registered worktree, such as a primary's own
+// tracked folders, are never returned, and a base that lies inside a registered
+// worktree is not scanned at all: FindOrphans then fails with ErrBaseInWorktree.
+func FindOrphans(cwd string) ([]string, error) {
+	base, err := WorktreeBasePath(cwd)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(base)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	wts, err := gitx.ListWorktrees(cwd)
+	if err != nil {
+		return nil, err
+	}
+	commonDir, err := gitx.CommonGitDir(cwd)
+	if err != nil {
+		return nil, err
+	}
+	flat, _ := gitx.ConfigGet(cwd, "gw.worktree.flat")
+	claimUnmarked := !strings.EqualFold(flat, "true")
+	// Without a remote the base mirrors the repository's path under local/, so the
+	// base of a repository nested in this one sits inside this base. base/<name> may be
+	// such a base whenever <root>/<name> is a directory.
+	nestedRoot := ""
+	if _, _, _, hasRemote, _ := ParseRemoteURL(cwd); !hasRemote && claimUnmarked {
+		nestedRoot, _ = PrimaryRoot(cwd)
+	}
+	var registered []string
+	for _, wt := range wts {
+		registered = append(registered, fsutil.RealPath(wt.Path))
+	}
+	if root, err := PrimaryRoot(cwd); err == nil {
+		registered = append(registered, fsutil.RealPath(root))
+	}
+	realBase := fsutil.RealPath(base)
+	for _, r := range registered {
+		if fsutil.IsWithin(realBase, r) {
+			return nil, fmt.Errorf("%w: %s is inside %s", ErrBaseInWorktree, base, r)
+		}
+	}
+
+	var orphans []string
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		p := filepath.Join(base, e.Name())
+		if overlapsAny(fsutil.RealPath(p), registered) {
+			continue
+		}
+		if nestedRoot != "" {
+			if fi, err := os.Stat(filepath.Join(nestedRoot, e.Name())); err == nil && fi.IsDir() {
+				continue
+			}
+		}
+		if hasNestedGit(p) {
+			continue
+		}
+		if orphanOf(p, fsutil.RealPath(commonDir), claimUnmarked) {
+			orphans = append(orphans, p)
+		}
+	}
+	return orphans, nil
+}
+
+// orphanOf reports whether dir is a leftover worktree of the repository whose common
+// git dir is commonDir: its .git file points to an admin directory of commonDir that
+// no longer exists, or, when claimUnmarked is set, it has no .git at all.
+func orphanOf(dir, commonDir string, claimUnmarked bool) bool {
+	gitPath := filepath.Join(dir, ".git")
+	fi, err := os.Lstat(gitPath)
+	if err != nil {
+		return claimUnmarked && errors.Is(err, os.ErrNotExist)
+	}
+	if !fi.Mode().IsRegular() {
+		return false
+	}
+	data, err := os.ReadFile(gitPath)
+	if err != nil {
+		return false
+	}
+	gitdir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir: ")
+	if !ok {
+		return false
+	}
+	if !filepath.IsAbs(gitdir) {
+		gitdir = filepath.Join(dir, gitdir)
+	}
+	if !strings.HasPrefix(gitdir, commonDir+string(filepath.Separator)+"worktrees"+string(filepath.Separator)) {
+		return false
+	}
+	_, err = os.Stat(gitdir)
+	return errors.Is(err, os.ErrNotExist)
+}
+
+// hasNestedGit reports whether any directory below dir, not dir itself, has a .git
+// entry, i.e. dir holds a repository or worktree that is not ours to delete.
+func hasNestedGit(dir string) bool {
+	found := false
+	_ = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		// Unreadable parts cannot be checked, so they count as occupied.
+		if err != nil || (d.Name() == ".git" && filepath.Dir(p) != dir) {
+			found = true
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return found
+}
+
+// overlapsAny reports whether dir is one of paths, an ancestor of one or inside one.
+func overlapsAny(dir string, paths []string) bool {
+	for _, p := range paths {
+		if fsutil.IsWithin(p, dir) || fsutil.IsWithin(dir, p) {
+			return true
+		}
+	}
+	return false
+}
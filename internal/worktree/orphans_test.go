@@ -0,0 +1,182 @@
+package worktree
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindOrphans_shouldReturnOnlyLeftoversOfThisRepository(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("GW_CALLER_CWD", "")
+	repo := filepath.Join(home, "repo")
+	if err := os.MkdirAll(repo, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	gitIn(t, repo, "init", "--initial-branch=main")
+	gitIn(t, repo, "commit", "--allow-empty", "-m", "init")
+
+	live, err := ComputeWorktreePath(repo, "feature/live")
+	if err != nil {
+		t.Fatalf("ComputeWorktreePath: %v", err)
+	}
+	gitIn(t, repo, "worktree", "add", live, "-b", "feature/live")
+	pruned, _ := ComputeWorktreePath(repo, "feature/pruned")
+	gitIn(t, repo, "worktree", "add", pruned, "-b", "feature/pruned")
+	// Forget the worktree but leave its directory behind.
+	if err := os.RemoveAll(filepath.Join(repo, ".git", "worktrees", filepath.Base(pruned))); err != nil {
+		t.Fatal(err)
+	}
+
+	base := filepath.Dir(live)
+	leftover := filepath.Join(base, "leftover")
+	if err := os.MkdirAll(filepath.Join(leftover, "node_modules"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	clone := filepath.Join(base, "clone")
+	if err := os.MkdirAll(clone, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	gitIn(t, clone, "init")
+	foreign := filepath.Join(base, "foreign")
+	if err := os.MkdirAll(foreign, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(foreign, ".git"), []byte("gitdir: /elsewhere/.git/worktrees/foreign\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	orphans, err := FindOrphans(repo)
+	if err != nil {
+		t.Fatalf("FindOrphans: %v", err)
+	}
+	got := map[string]bool{}
+	for _, o := range orphans {
+		got[o] = true
+	}
+	if len(orphans) != 2 || !got[leftover] || !got[pruned] {
+		t.Fatalf("expected leftover and pruned worktree, got %v", orphans)
+	}
+}
+
+func TestFindOrphans_shouldSkipUnmarkedDirectories_whenLayoutIsFlat(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("GW_CALLER_CWD", "")
+	repo := filepath.Join(home, "repo")
+	if err := os.MkdirAll(repo, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	gitIn(t, repo, "init", "--initial-branch=main")
+	gitIn(t, repo, "commit", "--allow-empty", "-m", "init")
+	gitIn(t, repo, "config", "gw.worktree.flat", "true")
+
+	pruned, err := ComputeWorktreePath(repo, "feature/pruned")
+	if err != nil {
+		t.Fatalf("ComputeWorktreePath: %v", err)
+	}
+	gitIn(t, repo, "worktree", "add", pruned, "-b", "feature/pruned")
+	if err := os.RemoveAll(filepath.Join(repo, ".git", "worktrees", filepath.Base(pruned))); err != nil {
+		t.Fatal(err)
+	}
+	// Another repository's leftover cannot be told apart in the shared flat base.
+	if err := os.MkdirAll(filepath.Join(filepath.Dir(pruned), "other-leftover"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	orphans, err := FindOrphans(repo)
+	if err != nil {
+		t.Fatalf("FindOrphans: %v", err)
+	}
+	if len(orphans) != 1 || orphans[0] != pruned {
+		t.Fatalf("expected only %s, got %v", pruned, orphans)
+	}
+}
+
+func TestFindOrphans_shouldSkipWorktreesOfNestedRepositories(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("GW_CALLER_CWD", "")
+	outer := filepath.Join(home, "work")
+	inner := filepath.Join(outer, "sub")
+	other := filepath.Join(outer, "other")
+	for _, repo := range []string{outer, inner, other} {
+		if err := os.MkdirAll(repo, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		gitIn(t, repo, "init", "--initial-branch=main")
+		gitIn(t, repo, "commit", "--allow-empty", "-m", "init")
+	}
+
+	// Without remotes the nested repositories' bases live inside the outer base.
+	innerWt, err := ComputeWorktreePath(inner, "feat-sub")
+	if err != nil {
+		t.Fatalf("ComputeWorktreePath: %v", err)
+	}
+	gitIn(t, inner, "worktree", "add", innerWt, "-b", "feat-sub")
+	otherBase, err := WorktreeBasePath(other)
+	if err != nil {
+		t.Fatalf("WorktreeBasePath: %v", err)
+	}
+	if err := os.MkdirAll(otherBase, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	base, err := WorktreeBasePath(outer)
+	if err != nil {
+		t.Fatalf("WorktreeBasePath: %v", err)
+	}
+	if filepath.Dir(filepath.Dir(innerWt)) != base || filepath.Dir(otherBase) != base {
+		t.Fatalf("expected nested bases inside %s, got %s and %s", base, innerWt, otherBase)
+	}
+	// A directory that holds a repository further down is never claimed either.
+	holder := filepath.Join(base, "holder")
+	if err := os.MkdirAll(filepath.Join(holder, "deep", "clone", ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	leftover := filepath.Join(base, "leftover")
+	if err := os.MkdirAll(leftover, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	orphans, err := FindOrphans(outer)
+	if err != nil {
+		t.Fatalf("FindOrphans: %v", err)
+	}
+	if len(orphans) != 1 || orphans[0] != leftover {
+		t.Fatalf("expected only %s, got %v", leftover, orphans)
+	}
+}
+
+func TestFindOrphans_shouldRefuseToScan_whenBaseIsInsideTheRepository(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("GW_CALLER_CWD", "")
+	repo := filepath.Join(home, "src", "github.com", "org", "repo")
+	for _, dir := range []string{"docs", "internal"} {
+		if err := os.MkdirAll(filepath.Join(repo, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(repo, dir, "README"), []byte("tracked\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gitIn(t, repo, "init", "--initial-branch=main")
+	gitIn(t, repo, "add", ".")
+	gitIn(t, repo, "commit", "-m", "init")
+	gitIn(t, repo, "remote", "add", "origin", "git@github.com:org/repo.git")
+	gitIn(t, repo, "config", "gw.worktree.base", "~/src")
+
+	base, err := WorktreeBasePath(repo)
+	if err != nil {
+		t.Fatalf("WorktreeBasePath: %v", err)
+	}
+	if base != repo {
+		t.Fatalf("expected the base to be the repository itself, got %s", base)
+	}
+	orphans, err := FindOrphans(repo)
+	if !errors.Is(err, ErrBaseInWorktree) {
+		t.Fatalf("expected ErrBaseInWorktree, got %v (orphans %v)", err, orphans)
+	}
+}
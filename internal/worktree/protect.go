@@ -0,0 +1,70 @@
+package worktree
+
+import (
+	"fmt"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/sh0o0/gw/internal/gitx"
+)
+
+const (
+	configKeyPin     = "gw.pin"
+	configKeyProtect = "gw.protect"
+)
+
+// Protection lists the branches whose worktrees must not be removed without
+// --force-protected: branches pinned with gw pin (gw.pin) and branches matching a
+// gw.protect glob.
+type Protection struct {
+	Pinned []string
+	Globs  []string
+}
+
+// LoadProtection reads gw.pin and gw.protect.
+func LoadProtection(cwd string) Protection {
+	pinned, _ := gitx.ConfigGetAll(cwd, configKeyPin)
+	globs, _ := gitx.ConfigGetAll(cwd, configKeyProtect)
+	return Protection{Pinned: pinned, Globs: globs}
+}
+
+// IsPinned reports whether branch was pinned with gw pin.
+func (p Protection) IsPinned(branch string) bool {
+	for _, b := range p.Pinned {
+		if b == branch {
+			return true
+		}
+	}
+	return false
+}
+
+// Reason returns why branch is protected, or "" if it is not.
+func (p Protection) Reason(branch string) string {
+	if branch == "" || branch == "HEAD" {
+		return ""
+	}
+	if p.IsPinned(branch) {
+		return "pinned"
+	}
+	for _, g := range p.Globs {
+		if ok, _ := doublestar.Match(g, branch); ok {
+			return fmt.Sprintf("matches gw.protect %q", g)
+		}
+	}
+	return ""
+}
+
+// Pin protects branch by adding it to gw.pin. It reports false if it already was.
+func Pin(cwd, branch string) (bool, error) {
+	if LoadProtection(cwd).IsPinned(branch) {
+		return false, nil
+	}
+	return true, gitx.ConfigAdd(cwd, configKeyPin, branch)
+}
+
+// Unpin removes branch from gw.pin. It reports false if it was not pinned.
+func Unpin(cwd, branch string) (bool, error) {
+	if !LoadProtection(cwd).IsPinned(branch) {
+		return false, nil
+	}
+	return true, gitx.ConfigUnsetValue(cwd, configKeyPin, branch)
+}
@@ -0,0 +1,48 @@
+package worktree
+
+import "testing"
+
+func TestProtectionReason_shouldReportPinsAndGlobs(t *testing.T) {
+	p := Protection{Pinned: []string{"sandbox"}, Globs: []string{"release/*"}}
+	cases := map[string]string{
+		"sandbox":       "pinned",
+		"release/1.2":   `matches gw.protect "release/*"`,
+		"release/1/fix": "",
+		"feature/x":     "",
+		"HEAD":          "",
+		"":              "",
+	}
+	for branch, want := range cases {
+		if got := p.Reason(branch); got != want {
+			t.Errorf("Reason(%q) = %q, want %q", branch, got, want)
+		}
+	}
+}
+
+func TestPin_shouldAddAndRemoveBranchOnce(t *testing.T) {
+	repo := t.TempDir()
+	gitIn(t, repo, "init", "--initial-branch=main")
+
+	if added, err := Pin(repo, "sandbox"); err != nil || !added {
+		t.Fatalf("Pin = %v, %v; want true, nil", added, err)
+	}
+	if added, err := Pin(repo, "sandbox"); err != nil || added {
+		t.Fatalf("second Pin = %v, %v; want false, nil", added, err)
+	}
+	if _, err := Pin(repo, "other"); err != nil {
+		t.Fatalf("Pin other: %v", err)
+	}
+	if !LoadProtection(repo).IsPinned("sandbox") {
+		t.Fatal("expected sandbox to be pinned")
+	}
+	if removed, err := Unpin(repo, "sandbox"); err != nil || !removed {
+		t.Fatalf("Unpin = %v, %v; want true, nil", removed, err)
+	}
+	if removed, err := Unpin(repo, "sandbox"); err != nil || removed {
+		t.Fatalf("second Unpin = %v, %v; want false, nil", removed, err)
+	}
+	p := LoadProtection(repo)
+	if p.IsPinned("sandbox") || !p.IsPinned("other") {
+		t.Fatalf("unexpected pins after Unpin: %v", p.Pinned)
+	}
+}
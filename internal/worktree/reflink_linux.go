@@ -0,0 +1,31 @@
+package worktree
+
+import (
+	"os"
+	"syscall"
+)
+
+// ficlone is FICLONE from linux/fs.h.
+const ficlone = 0x40049409
+
+// cloneFile creates dst as a copy-on-write clone of src.
+func cloneFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	fi, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, fi.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, out.Fd(), ficlone, in.Fd()); errno != 0 {
+		return errno
+	}
+	return nil
+}
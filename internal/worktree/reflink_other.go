@@ -0,0 +1,10 @@
+//go:build !linux
+
+package worktree
+
+import "errors"
+
+// cloneFile is only implemented on Linux; callers fall back to a plain copy.
+func cloneFile(src, dst string) error {
+	return errors.New("reflink not supported on this platform")
+}
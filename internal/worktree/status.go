@@ -0,0 +1,228 @@
+package worktree
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/sh0o0/gw/internal/fsutil"
+)
+
+// LinkState classifies a managed path in a worktree.
+type LinkState string
+
+const (
+	// LinkOK is a symlink resolving to its source, or an up-to-date copy.
+	LinkOK LinkState = "ok"
+	// LinkMissing means the source exists but the worktree has nothing at the path.
+	LinkMissing LinkState = "missing"
+	// LinkDangling is a symlink whose target does not exist.
+	LinkDangling LinkState = "dangling"
+	// LinkWrongTarget is a symlink resolving to something other than its source, or a
+	// symlink where the mode asks for a copy.
+	LinkWrongTarget LinkState = "wrong-target"
+	// LinkStale is a copy gw placed whose source has changed since.
+	LinkStale LinkState = "stale"
+	// LinkConflict is a real file where a symlink should be, or a copy edited locally.
+	LinkConflict LinkState = "conflict"
+	// LinkSource is a file of the source worktree itself.
+	LinkSource LinkState = "source"
+)
+
+// LinkStatus describes one path matched by gw.symlink.include in a worktree.
+type LinkStatus struct {
+	Worktree string    `json:"worktree"`
+	Path     string    `json:"path"`
+	Mode     Mode      `json:"mode"`
+	State    LinkState `json:"state"`
+	// Source is the file in the source worktree; empty when it no longer exists.
+	Source string `json:"source,omitempty"`
+	// Link is the target of the symlink at Path, as stored.
+	Link string `json:"link,omitempty"`
+}
+
+// CheckLinks reports the state of every path of target that gw manages from root:
+// gitignored files of root matching gw.symlink.include, plus symlinks in target
+// matching it whose source has gone.
+func CheckLinks(root, target string) ([]LinkStatus, error) {
+	files, err := GitIgnoredFiles(root)
+	if err != nil {
+		return nil, err
+	}
+	pats := IncludePatterns(root)
+	excludes := ExcludePatterns(root)
+	def := ModeFromConfig(root)
+	self := fsutil.SamePath(root, target)
+	var manifest *copyManifest
+	seen := map[string]bool{}
+	var res []LinkStatus
+	for _, f := range files {
+		if shouldExclude(f, excludes) {
+			continue
+		}
+		mode, ok := matchPattern("/"+f, pats, def)
+		if !ok {
+			continue
+		}
+		seen[f] = true
+		st := LinkStatus{Worktree: target, Path: f, Mode: mode, Source: filepath.Join(root, f)}
+		if self {
+			st.State = LinkSource
+		} else {
+			if manifest == nil && mode != ModeSymlink {
+				manifest = loadCopyManifest(target)
+			}
+			checkLink(&st, manifest)
+		}
+		res = append(res, st)
+	}
+	if !self {
+		// Symlinks whose source was deleted are no longer listed in root.
+		others, _ := GitIgnoredFiles(target)
+		for _, f := range others {
+			if seen[f] || shouldExclude(f, excludes) {
+				continue
+			}
+			mode, ok := matchPattern("/"+f, pats, def)
+			if !ok {
+				continue
+			}
+			fi, err := os.Lstat(filepath.Join(target, f))
+			if err != nil || fi.Mode()&os.ModeSymlink == 0 {
+				continue
+			}
+			st := LinkStatus{Worktree: target, Path: f, Mode: mode}
+			checkLink(&st, nil)
+			res = append(res, st)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Path < res[j].Path })
+	return res, nil
+}
+
+func checkLink(st *LinkStatus, manifest *copyManifest) {
+	dst := filepath.Join(st.Worktree, st.Path)
+	fi, err := os.Lstat(dst)
+	if err != nil {
+		st.State = LinkMissing
+		return
+	}
+	if fi.Mode()&os.ModeSymlink != 0 {
+		st.Link, _ = os.Readlink(dst)
+		resolved, err := filepath.EvalSymlinks(dst)
+		switch {
+		case err != nil:
+			st.State = LinkDangling
+		case st.Source == "" || st.Mode != ModeSymlink:
+			st.State = LinkWrongTarget
+		default:
+			want, err := filepath.EvalSymlinks(st.Source)
+			if err == nil && want == resolved {
+				st.State = LinkOK
+			} else {
+				st.State = LinkWrongTarget
+			}
+		}
+		return
+	}
+	if st.Mode == ModeSymlink {
+		st.State = LinkConflict
+		return
+	}
+	src := st.Source
+	if actual, err := filepath.EvalSymlinks(src); err == nil {
+		src = actual
+	}
+	if st.Mode == ModeHardlink && sameFile(src, dst) {
+		st.State = LinkOK
+		return
+	}
+	srcHash, err1 := hashPath(src)
+	dstHash, err2 := hashPath(dst)
+	prev, tracked := manifest.Entries[st.Path]
+	switch {
+	case err1 != nil || err2 != nil:
+		st.State = LinkConflict
+	case srcHash == dstHash:
+		st.State = LinkOK
+	case tracked && prev.Hash == dstHash:
+		st.State = LinkStale
+	default:
+		st.State = LinkConflict
+	}
+}
+
+// RepairAction is what RepairLinks did to a path.
+type RepairAction string
+
+const (
+	RepairCreated   RepairAction = "created"
+	RepairRepointed RepairAction = "re-pointed"
+	RepairRefreshed RepairAction = "refreshed"
+	RepairRemoved   RepairAction = "removed"
+)
+
+// Repair records a path RepairLinks changed.
+type Repair struct {
+	LinkStatus
+	Action RepairAction `json:"action"`
+}
+
+// RepairLinks fixes what CheckLinks reports for target: missing entries are created,
+// dangling and wrong-target links re-pointed to their source, stale copies refreshed
+// and links whose source is gone removed. Real files are never touched, so
+// conflicts remain.
+func RepairLinks(root, target string, opts SymlinkOptions) ([]Repair, error) {
+	// Creating links would otherwise recreate a deleted worktree folder.
+	if _, err := os.Stat(target); err != nil {
+		return nil, fmt.Errorf("worktree not found: %w", err)
+	}
+	statuses, err := CheckLinks(root, target)
+	if err != nil {
+		return nil, err
+	}
+	opts.Conflict = ConflictSkip
+	opts.Relative = opts.Relative || RelativeFromConfig(root)
+	var manifest *copyManifest
+	var repairs []Repair
+	var res SymlinkResult
+	for _, st := range statuses {
+		var action RepairAction
+		switch st.State {
+		case LinkMissing:
+			action = RepairCreated
+		case LinkDangling, LinkWrongTarget:
+			action = RepairRepointed
+			if st.Source == "" {
+				action = RepairRemoved
+			}
+		case LinkStale:
+			action = RepairRefreshed
+		default:
+			continue
+		}
+		dst := filepath.Join(target, st.Path)
+		switch {
+		case action == RepairRemoved:
+			err = os.Remove(dst)
+		case st.Mode == ModeSymlink:
+			err = Link(st.Source, dst, opts, &res)
+		default:
+			if manifest == nil {
+				manifest = loadCopyManifest(target)
+			}
+			err = place(st.Source, dst, st.Path, st.Mode, manifest, opts, &res)
+		}
+		if err != nil {
+			break
+		}
+		repairs = append(repairs, Repair{LinkStatus: st, Action: action})
+	}
+	if manifest != nil {
+		if serr := manifest.save(); err == nil {
+			err = serr
+		}
+	}
+	return repairs, err
+}
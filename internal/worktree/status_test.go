@@ -0,0 +1,125 @@
+package worktree
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func gitIn(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	args = append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+// setupLinkedRepo creates a repository sharing .env and .secret with a linked worktree.
+func setupLinkedRepo(t *testing.T) (primary, wt string) {
+	t.Helper()
+	dir, _ := filepath.EvalSymlinks(t.TempDir())
+	primary = filepath.Join(dir, "repo")
+	wt = filepath.Join(dir, "wt")
+	if err := os.MkdirAll(primary, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	gitIn(t, primary, "init", "-q")
+	gitIn(t, primary, "config", "--add", "gw.symlink.include", "**/.env")
+	gitIn(t, primary, "config", "--add", "gw.symlink.include", "**/.secret")
+	for name, content := range map[string]string{".gitignore": ".env\n.secret\n.orphan\n", ".env": "A=1", ".secret": "s"} {
+		if err := os.WriteFile(filepath.Join(primary, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gitIn(t, primary, "add", ".gitignore")
+	gitIn(t, primary, "commit", "-qm", "init")
+	gitIn(t, primary, "worktree", "add", "-q", "-b", "feature", wt)
+	return primary, wt
+}
+
+func statesByPath(statuses []LinkStatus) map[string]LinkState {
+	m := map[string]LinkState{}
+	for _, st := range statuses {
+		m[st.Path] = st.State
+	}
+	return m
+}
+
+func TestCheckLinks_shouldReportMissingDanglingAndWrongTarget(t *testing.T) {
+	primary, wt := setupLinkedRepo(t)
+	if err := os.WriteFile(filepath.Join(primary, ".orphan"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gitIn(t, primary, "config", "--add", "gw.symlink.include", "**/.orphan")
+	if err := os.Symlink(filepath.Join(primary, ".orphan"), filepath.Join(wt, ".orphan")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(primary, ".orphan")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(primary, ".gitignore"), filepath.Join(wt, ".secret")); err != nil {
+		t.Fatal(err)
+	}
+
+	statuses, err := CheckLinks(primary, wt)
+	if err != nil {
+		t.Fatalf("CheckLinks: %v", err)
+	}
+	got := statesByPath(statuses)
+	want := map[string]LinkState{".env": LinkMissing, ".secret": LinkWrongTarget, ".orphan": LinkDangling}
+	for p, s := range want {
+		if got[p] != s {
+			t.Fatalf("%s: expected %s, got %+v", p, s, statuses)
+		}
+	}
+}
+
+func TestRepairLinks_shouldFixBrokenLinks_andLeaveRealFiles(t *testing.T) {
+	primary, wt := setupLinkedRepo(t)
+	gitIn(t, primary, "config", "--add", "gw.symlink.include", "**/.orphan")
+	if err := os.Symlink(filepath.Join(primary, ".orphan"), filepath.Join(wt, ".orphan")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(wt, ".secret"), []byte("local"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	repairs, err := RepairLinks(primary, wt, SymlinkOptions{})
+	if err != nil {
+		t.Fatalf("RepairLinks: %v", err)
+	}
+	if len(repairs) != 2 {
+		t.Fatalf("expected 2 repairs, got %+v", repairs)
+	}
+	if _, err := os.Lstat(filepath.Join(wt, ".orphan")); !os.IsNotExist(err) {
+		t.Fatalf("expected orphaned link to be removed: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(wt, ".secret")); string(data) != "local" {
+		t.Fatalf("real file must be left alone, got %q", data)
+	}
+
+	statuses, err := CheckLinks(primary, wt)
+	if err != nil {
+		t.Fatalf("CheckLinks: %v", err)
+	}
+	got := statesByPath(statuses)
+	if got[".env"] != LinkOK || got[".secret"] != LinkConflict || len(got) != 2 {
+		t.Fatalf("unexpected states after repair: %+v", statuses)
+	}
+}
+
+func TestRepairLinks_shouldFail_whenWorktreeIsGone(t *testing.T) {
+	primary, wt := setupLinkedRepo(t)
+	if err := os.RemoveAll(wt); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RepairLinks(primary, wt, SymlinkOptions{}); err == nil {
+		t.Fatal("expected an error for a missing worktree")
+	}
+	if _, err := os.Stat(wt); !os.IsNotExist(err) {
+		t.Fatalf("expected worktree folder not to be recreated: %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+package worktree
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// WatchOptions configures Watch.
+type WatchOptions struct {
+	// Debounce is how long the source must be quiet before a sync runs.
+	Debounce time.Duration
+	// Poll is the rescan interval used when file system notifications are unavailable.
+	Poll time.Duration
+	// Targets returns the worktrees to keep in sync; it is called before every sync so
+	// worktrees added while watching are picked up.
+	Targets func() ([]string, error)
+	// Report receives what each sync changed in a target. It is not called for targets
+	// that needed nothing.
+	Report func(target string, repairs []Repair, err error)
+	// Polling is called once if Watch falls back to polling, with the reason. This
+	// happens at start when notifications are unavailable, or later if the watcher
+	// stops.
+	Polling func(reason error)
+}
+
+// Watch keeps the managed links of every target in sync with root until ctx is done.
+// It watches root with inotify where available and otherwise rescans every
+// opts.Poll. Each sync is a RepairLinks, so new files matching gw.symlink.include
+// are linked right away and links to deleted files are removed.
+func Watch(ctx context.Context, root string, opts WatchOptions) error {
+	if opts.Debounce <= 0 {
+		opts.Debounce = 500 * time.Millisecond
+	}
+	if opts.Poll <= 0 {
+		opts.Poll = 2 * time.Second
+	}
+	excludes := ExcludePatterns(root)
+	pats := IncludePatterns(root)
+	skipDir := func(rel string) bool {
+		if filepath.Base(rel) == ".git" || shouldExclude(rel, excludes) {
+			return true
+		}
+		// Another worktree nested in root: its own links must not retrigger syncs.
+		fi, err := os.Lstat(filepath.Join(root, rel, ".git"))
+		return err == nil && !fi.IsDir()
+	}
+	relevant := func(rel string) bool {
+		_, ok := matchPattern("/"+rel, pats, ModeSymlink)
+		return rel == "" || ok
+	}
+
+	sync := func() {
+		targets, err := opts.Targets()
+		if err != nil {
+			opts.Report("", nil, err)
+			return
+		}
+		for _, t := range targets {
+			repairs, err := RepairLinks(root, t, SymlinkOptions{})
+			if len(repairs) > 0 || err != nil {
+				opts.Report(t, repairs, err)
+			}
+		}
+	}
+	sync()
+
+	var events <-chan string
+	var ticker <-chan time.Time
+	var poll *time.Ticker
+	defer func() {
+		if poll != nil {
+			poll.Stop()
+		}
+	}()
+	startPolling := func(reason error) {
+		if opts.Polling != nil {
+			opts.Polling(reason)
+		}
+		poll = time.NewTicker(opts.Poll)
+		ticker = poll.C
+	}
+	w, err := startFSWatcher(root, skipDir)
+	if err != nil {
+		startPolling(err)
+	} else {
+		defer w.Close()
+		events = w.Events()
+	}
+
+	var debounce *time.Timer
+	var fire <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker:
+			sync()
+		case rel, ok := <-events:
+			if !ok {
+				// The watcher died; keep syncing rather than silently stop.
+				events = nil
+				reason := w.Err()
+				if reason == nil {
+					reason = errors.New("file watcher stopped")
+				}
+				startPolling(reason)
+				sync()
+				continue
+			}
+			if !relevant(rel) {
+				continue
+			}
+			if debounce == nil {
+				debounce = time.NewTimer(opts.Debounce)
+			} else {
+				debounce.Reset(opts.Debounce)
+			}
+			fire = debounce.C
+		case <-fire:
+			fire = nil
+			sync()
+		}
+	}
+}
+
+// fsWatcher reports paths, relative to the watched root, that were created, written,
+// moved or deleted. An empty path means events were lost and everything may have
+// changed.
+type fsWatcher interface {
+	Events() <-chan string
+	// Err returns why Events was closed, if it closed on its own.
+	Err() error
+	Close() error
+}
+
+// startFSWatcher is newFSWatcher, replaceable in tests.
+var startFSWatcher = newFSWatcher
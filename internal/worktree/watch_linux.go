@@ -0,0 +1,153 @@
+package worktree
+
+import (
+	"encoding/binary"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+const inotifyMask = syscall.IN_CREATE | syscall.IN_CLOSE_WRITE | syscall.IN_MOVED_TO |
+	syscall.IN_MOVED_FROM | syscall.IN_DELETE
+
+type inotifyWatcher struct {
+	f       *os.File
+	fd      int
+	root    string
+	skipDir func(rel string) bool
+	events  chan string
+	done    chan struct{}
+	once    sync.Once
+	err     error
+
+	mu   sync.Mutex
+	dirs map[int32]string
+}
+
+// newFSWatcher watches every directory under root that skipDir does not prune.
+func newFSWatcher(root string, skipDir func(rel string) bool) (fsWatcher, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+	w := &inotifyWatcher{
+		// A non-blocking fd is served by the runtime poller, so Close unblocks Read.
+		f:       os.NewFile(uintptr(fd), "inotify"),
+		fd:      fd,
+		root:    root,
+		skipDir: skipDir,
+		events:  make(chan string, 64),
+		done:    make(chan struct{}),
+		dirs:    map[int32]string{},
+	}
+	if err := w.addTree(root, nil); err != nil {
+		w.f.Close()
+		return nil, err
+	}
+	go w.read()
+	return w, nil
+}
+
+func (w *inotifyWatcher) Events() <-chan string { return w.events }
+
+// Err is only meaningful once Events is closed.
+func (w *inotifyWatcher) Err() error { return w.err }
+
+func (w *inotifyWatcher) Close() error {
+	w.once.Do(func() { close(w.done) })
+	return w.f.Close()
+}
+
+// emit delivers an event unless the watcher was closed.
+func (w *inotifyWatcher) emit(rel string) {
+	select {
+	case w.events <- rel:
+	case <-w.done:
+	}
+}
+
+// addTree adds watches for dir and its subdirectories. Files found are passed to found,
+// so files created together with a new directory are not missed.
+func (w *inotifyWatcher) addTree(dir string, found func(rel string)) error {
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// The directory may be gone already; only the root must exist.
+			if p == dir {
+				return err
+			}
+			return nil
+		}
+		rel, _ := filepath.Rel(w.root, p)
+		if rel == "." {
+			rel = ""
+		}
+		if !d.IsDir() {
+			if found != nil {
+				found(rel)
+			}
+			return nil
+		}
+		if rel != "" && w.skipDir(rel) {
+			return filepath.SkipDir
+		}
+		wd, err := syscall.InotifyAddWatch(w.fd, p, inotifyMask)
+		if err != nil {
+			// Typically the inotify watch limit: without a complete view, give up so
+			// the caller can fall back to polling.
+			return err
+		}
+		w.mu.Lock()
+		w.dirs[int32(wd)] = p
+		w.mu.Unlock()
+		return nil
+	})
+}
+
+func (w *inotifyWatcher) read() {
+	defer close(w.events)
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := w.f.Read(buf)
+		if err != nil {
+			select {
+			case <-w.done:
+			default:
+				w.err = err
+			}
+			return
+		}
+		for off := 0; off+syscall.SizeofInotifyEvent <= n; {
+			wd := int32(binary.NativeEndian.Uint32(buf[off:]))
+			mask := binary.NativeEndian.Uint32(buf[off+4:])
+			nameLen := int(binary.NativeEndian.Uint32(buf[off+12:]))
+			name := strings.TrimRight(string(buf[off+syscall.SizeofInotifyEvent:off+syscall.SizeofInotifyEvent+nameLen]), "\x00")
+			off += syscall.SizeofInotifyEvent + nameLen
+
+			if mask&syscall.IN_Q_OVERFLOW != 0 {
+				w.emit("")
+				continue
+			}
+			w.mu.Lock()
+			dir, ok := w.dirs[wd]
+			if mask&syscall.IN_IGNORED != 0 {
+				delete(w.dirs, wd)
+			}
+			w.mu.Unlock()
+			if !ok || name == "" {
+				continue
+			}
+			p := filepath.Join(dir, name)
+			rel, _ := filepath.Rel(w.root, p)
+			if mask&syscall.IN_ISDIR != 0 && mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0 {
+				if !w.skipDir(rel) {
+					_ = w.addTree(p, w.emit)
+				}
+				continue
+			}
+			w.emit(rel)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package worktree
+
+import "errors"
+
+// newFSWatcher is only implemented on Linux; Watch falls back to polling.
+func newFSWatcher(root string, skipDir func(rel string) bool) (fsWatcher, error) {
+	return nil, errors.New("file system notifications are not supported on this platform")
+}
@@ -0,0 +1,127 @@
+package worktree
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWatch_shouldLinkNewFilesIntoWorktrees(t *testing.T) {
+	primary, wt := setupLinkedRepo(t)
+	if err := os.Remove(filepath.Join(primary, ".secret")); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var mu sync.Mutex
+	var created []string
+	done := make(chan error, 1)
+	go func() {
+		done <- Watch(ctx, primary, WatchOptions{
+			Debounce: 50 * time.Millisecond,
+			Poll:     100 * time.Millisecond,
+			Targets:  func() ([]string, error) { return []string{wt}, nil },
+			Report: func(target string, repairs []Repair, err error) {
+				mu.Lock()
+				defer mu.Unlock()
+				for _, r := range repairs {
+					created = append(created, r.Path)
+				}
+			},
+		})
+	}()
+	defer func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Watch: %v", err)
+		}
+	}()
+
+	waitFor := func(path string) {
+		t.Helper()
+		deadline := time.Now().Add(3 * time.Second)
+		for time.Now().Before(deadline) {
+			if _, err := os.Lstat(path); err == nil {
+				return
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		t.Fatalf("timed out waiting for %s", path)
+	}
+	waitFor(filepath.Join(wt, ".env"))
+
+	if err := os.WriteFile(filepath.Join(primary, ".secret"), []byte("new"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(filepath.Join(wt, ".secret"))
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(created) != 2 || created[0] != ".env" || created[1] != ".secret" {
+		t.Fatalf("unexpected reports: %v", created)
+	}
+}
+
+type deadWatcher struct{ events chan string }
+
+func (w *deadWatcher) Events() <-chan string { return w.events }
+func (w *deadWatcher) Err() error            { return errors.New("read failed") }
+func (w *deadWatcher) Close() error          { return nil }
+
+func TestWatch_shouldFallBackToPolling_whenWatcherStops(t *testing.T) {
+	primary, wt := setupLinkedRepo(t)
+	if err := os.Remove(filepath.Join(primary, ".secret")); err != nil {
+		t.Fatal(err)
+	}
+	orig := startFSWatcher
+	defer func() { startFSWatcher = orig }()
+	startFSWatcher = func(string, func(string) bool) (fsWatcher, error) {
+		w := &deadWatcher{events: make(chan string)}
+		close(w.events)
+		return w, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	polling := make(chan error, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- Watch(ctx, primary, WatchOptions{
+			Poll:    50 * time.Millisecond,
+			Targets: func() ([]string, error) { return []string{wt}, nil },
+			Report:  func(string, []Repair, error) {},
+			Polling: func(reason error) { polling <- reason },
+		})
+	}()
+	defer func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Watch: %v", err)
+		}
+	}()
+
+	select {
+	case reason := <-polling:
+		if reason == nil || reason.Error() != "read failed" {
+			t.Fatalf("unexpected polling reason: %v", reason)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("expected Watch to fall back to polling")
+	}
+	if err := os.WriteFile(filepath.Join(primary, ".secret"), []byte("new"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		if _, err := os.Lstat(filepath.Join(wt, ".secret")); err == nil {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected polling to keep syncing")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
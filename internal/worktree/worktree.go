@@ -12,9 +12,13 @@ import (
 	"github.com/sh0o0/gw/internal/gitx"
 )
 
+// SymlinkPatterns returns the gw.symlink.include globs without their mode prefixes.
 func SymlinkPatterns(cwd string) []string {
-	patterns, _ := gitx.ConfigGetAll(cwd, "gw.symlink.include")
-	return patterns
+	var globs []string
+	for _, p := range IncludePatterns(cwd) {
+		globs = append(globs, p.Glob)
+	}
+	return globs
 }
 
 func ExcludePatterns(cwd string) []string {
@@ -97,7 +101,8 @@ func worktreeBasePathWithConfig(configBase string, flat bool, home, root, domain
 	return filepath.Join(base, "local")
 }
 
-func primaryRoot(cwd string) (string, error) {
+// PrimaryRoot returns the primary worktree of the repository containing cwd.
+func PrimaryRoot(cwd string) (string, error) {
 	commonDir, err := gitx.CommonGitDir(cwd)
 	if err != nil {
 		return gitx.Root(cwd)
@@ -110,7 +115,7 @@ func primaryRoot(cwd string) (string, error) {
 }
 
 func WorktreeBasePath(cwd string) (string, error) {
-	root, err := primaryRoot(cwd)
+	root, err := PrimaryRoot(cwd)
 	if err != nil {
 		return "", err
 	}
@@ -149,39 +154,50 @@ func ComputeWorktreePath(cwd, branch string) (string, error) {
 	return p, nil
 }
 
-func GitIgnoredFiles(root string) ([]string, error) {
-	out, err := gitx.Cmd(root, "ls-files", "--others", "-i", "--exclude-standard")
-	if err != nil {
-		return nil, err
-	}
-	var res []string
-	for _, ln := range strings.Split(strings.TrimSpace(out), "\n") {
-		ln = strings.TrimSpace(ln)
-		if ln == "" {
-			continue
-		}
-		res = append(res, ln)
-	}
-	return res, nil
-}
-
 type SymlinkOptions struct {
 	Verbose bool
+	// Conflict applies when a destination is a real file or directory.
+	// Empty means gw.symlink.conflict (default backup).
+	Conflict ConflictPolicy
+	// Prompt chooses a policy for dst under ConflictPrompt. Nil means backup.
+	Prompt func(dst string) ConflictPolicy
+	// Relative creates links relative to their directory. CreateSymlinksFromGitignored
+	// and RepairLinks also turn it on for gw.symlink.relative=true; Link only honours
+	// this field, so its callers resolve the setting once with RelativeFromConfig.
+	Relative bool
 }
 
-func CreateSymlinksFromGitignored(root, target string, opts SymlinkOptions) (int, error) {
+const configKeySymlinkRelative = "gw.symlink.relative"
+
+// RelativeFromConfig reports whether gw.symlink.relative is true.
+func RelativeFromConfig(cwd string) bool {
+	v, _ := gitx.ConfigGet(cwd, configKeySymlinkRelative)
+	return strings.EqualFold(strings.TrimSpace(v), "true")
+}
+
+// CreateSymlinksFromGitignored places the gitignored files of root matching
+// gw.symlink.include into target, each with the mode of its pattern (default
+// gw.symlink.mode, else symlink).
+func CreateSymlinksFromGitignored(root, target string, opts SymlinkOptions) (SymlinkResult, error) {
+	var res SymlinkResult
 	files, err := GitIgnoredFiles(root)
 	if err != nil {
-		return 0, err
+		return res, err
+	}
+	if opts.Conflict == "" {
+		opts.Conflict = ConflictPolicyFromConfig(root)
 	}
-	pats := SymlinkPatterns(root)
+	opts.Relative = opts.Relative || RelativeFromConfig(root)
+	pats := IncludePatterns(root)
 	excludes := ExcludePatterns(root)
-	count := 0
+	def := ModeFromConfig(root)
+	var manifest *copyManifest
 	for _, f := range files {
 		if shouldExclude(f, excludes) {
 			continue
 		}
-		if !matchAnyPattern("/"+f, pats) { // fish version matches against leading slash
+		mode, ok := matchPattern("/"+f, pats, def) // fish version matches against leading slash
+		if !ok {
 			continue
 		}
 		src := filepath.Join(root, f)
@@ -189,21 +205,54 @@ func CreateSymlinksFromGitignored(root, target string, opts SymlinkOptions) (int
 		if _, err := os.Lstat(src); err != nil {
 			continue
 		}
-
-		// Resolve symlink chain to get the actual file
-		actualSrc, err := filepath.EvalSymlinks(src)
+		if mode == ModeSymlink {
+			err = Link(src, dst, opts, &res)
+		} else {
+			if manifest == nil {
+				manifest = loadCopyManifest(target)
+			}
+			err = place(src, dst, f, mode, manifest, opts, &res)
+		}
 		if err != nil {
-			// If we can't resolve, use the original src
-			actualSrc = src
+			break
 		}
-
-		if err := fsutil.CreateSymlink(actualSrc, dst); err != nil {
-			return count, err
+	}
+	if manifest != nil {
+		if serr := manifest.save(); err == nil {
+			err = serr
 		}
-		if opts.Verbose {
-			fmt.Fprintf(os.Stderr, "Created symlink: %s -> %s\n", dst, actualSrc)
+	}
+	return res, err
+}
+
+// Link creates a symlink at dst pointing to src (with its symlink chain resolved),
+// applying opts.Conflict when dst is a real file. Outcomes are added to res.
+func Link(src, dst string, opts SymlinkOptions, res *SymlinkResult) error {
+	if opts.Conflict == "" {
+		opts.Conflict = ConflictPolicyFromConfig(filepath.Dir(src))
+	}
+	// Resolve symlink chain to get the actual file
+	actualSrc, err := filepath.EvalSymlinks(src)
+	if err != nil {
+		// If we can't resolve, use the original src
+		actualSrc = src
+	}
+	ok, err := resolveConflict(dst, opts, res)
+	if err != nil || !ok {
+		return err
+	}
+	target := actualSrc
+	if opts.Relative {
+		if target, err = fsutil.RelativeTarget(actualSrc, dst); err != nil {
+			return err
 		}
-		count++
 	}
-	return count, nil
+	if err := fsutil.CreateSymlink(target, dst); err != nil {
+		return err
+	}
+	if opts.Verbose {
+		fmt.Fprintf(os.Stderr, "Created symlink: %s -> %s\n", dst, target)
+	}
+	res.Created++
+	return nil
 }
@@ -224,3 +224,61 @@ func parseRemoteURLString(u string) (string, string, string, bool, error) {
 	}
 	return "", "", "", false, nil
 }
+
+func TestLink_shouldCreateRelativeSymlink_whenRelative(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "repo", "app", ".env")
+	dst := filepath.Join(dir, "worktrees", "feature", "app", ".env")
+	if err := os.MkdirAll(filepath.Dir(src), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(src, []byte("shared"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var res SymlinkResult
+	if err := Link(src, dst, SymlinkOptions{Relative: true}, &res); err != nil {
+		t.Fatalf("Link: %v", err)
+	}
+	target, err := os.Readlink(dst)
+	if err != nil || filepath.IsAbs(target) {
+		t.Fatalf("expected relative target, got %q err=%v", target, err)
+	}
+
+	// Moving the whole tree keeps the link working.
+	moved := filepath.Join(t.TempDir(), "moved")
+	if err := os.Rename(dir, moved); err != nil {
+		t.Fatal(err)
+	}
+	movedDst := filepath.Join(moved, "worktrees", "feature", "app", ".env")
+	if data, err := os.ReadFile(movedDst); err != nil || string(data) != "shared" {
+		t.Fatalf("expected link to survive the move, got %q err=%v", data, err)
+	}
+
+	if _, err := fsutil.MaterializeSymlink(movedDst); err != nil {
+		t.Fatalf("MaterializeSymlink: %v", err)
+	}
+	if fi, err := os.Lstat(movedDst); err != nil || !fi.Mode().IsRegular() {
+		t.Fatalf("expected a real file after unlink, got %v err=%v", fi, err)
+	}
+}
+
+func TestLink_shouldHonourOnlyOptions_whenRelativeConfigured(t *testing.T) {
+	repo := t.TempDir()
+	gitIn(t, repo, "init", "--initial-branch=main")
+	gitIn(t, repo, "config", configKeySymlinkRelative, "true")
+	src := filepath.Join(repo, ".env")
+	if err := os.WriteFile(src, []byte("shared"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	dst := filepath.Join(t.TempDir(), ".env")
+
+	var res SymlinkResult
+	if err := Link(src, dst, SymlinkOptions{}, &res); err != nil {
+		t.Fatalf("Link: %v", err)
+	}
+	// Callers resolve gw.symlink.relative once; Link itself does not read it.
+	if target, err := os.Readlink(dst); err != nil || !filepath.IsAbs(target) {
+		t.Fatalf("expected absolute target, got %q err=%v", target, err)
+	}
+}